@@ -0,0 +1,109 @@
+package listener
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	BatchOptions struct {
+		// Size flushes a batch once it holds Size values.
+		Size int
+		// Every flushes a batch Every after its first value.
+		// Without Size and Every every value is its own batch.
+		Every time.Duration
+		Clock Clock
+	}
+
+	// batched collects broadcasts and passes them on to the wrapped
+	// listener as []interface{} batches.
+	batched struct {
+		wrapper
+		opts  BatchOptions
+		mu    sync.Mutex
+		batch []interface{}
+		timer  Timer
+		gen    uint64
+		closed bool
+	}
+)
+
+var (
+	_ Listener  = &batched{}
+	_ Inspector = &batched{}
+	_ Closer    = &batched{}
+)
+
+// NewBatched returns a listener that broadcasts its values to li in
+// []interface{} batches. Wrap a replay listener to read every batch
+// through Subscribe rather than just the latest.
+func NewBatched(li Listener, opts BatchOptions) Listener {
+	if opts.Size <= 0 && opts.Every <= 0 {
+		opts.Size = 1
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+
+	return &batched{
+		wrapper: wrapper{li},
+		opts:    opts,
+	}
+}
+
+// BatchCreater wraps the listeners made by creater with NewBatched.
+func BatchCreater(creater func() Listener, opts BatchOptions) func() Listener {
+	return func() Listener {
+		return NewBatched(creater(), opts)
+	}
+}
+
+func (b *batched) Broadcast(value interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.batch = append(b.batch, value)
+	switch {
+	case b.opts.Size > 0 && len(b.batch) >= b.opts.Size:
+		b.flush()
+	case len(b.batch) == 1 && b.opts.Every > 0:
+		gen := b.gen
+		b.timer = afterFunc(b.opts.Clock, b.opts.Every, func() {
+			b.mu.Lock()
+			if gen == b.gen {
+				b.flush()
+			}
+			b.mu.Unlock()
+		})
+	}
+}
+
+// flush must be called with b.mu held.
+func (b *batched) flush() {
+	b.gen++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.batch) != 0 {
+		batch := b.batch
+		b.batch = nil
+		b.Listener.Broadcast(batch)
+	}
+}
+
+// Close flushes the open batch and then closes the wrapped listener.
+// Later broadcasts are dropped.
+func (b *batched) Close(err error) {
+	b.mu.Lock()
+	b.closed = true
+	b.flush()
+	b.mu.Unlock()
+
+	if c, ok := b.Listener.(Closer); ok {
+		c.Close(err)
+	}
+}
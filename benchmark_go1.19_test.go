@@ -0,0 +1,154 @@
+//go:build go1.19
+// +build go1.19
+
+package listener
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func BenchmarkThreadsResendDenseInt(b *testing.B) {
+	var d uint32
+
+	m := initMap()
+	obs := NewDenseIntListeners(steps)
+
+	b.SetParallelism(benchWorks)
+	b.ReportAllocs()
+	b.SetBytes(2)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		dd := atomic.AddUint32(&d, 1)
+		disp := dispersions[int(dd)%benchWorks]
+		var found bool
+		var key string
+		var keyInt int
+		var l Listener
+		var i int
+		for pb.Next() {
+			keyInt = i % steps
+			key = disp[keyInt]
+			if _, found = m[key]; found {
+				continue
+			}
+
+			l, found = obs.GetOrCreate(keyInt)
+			if !found {
+				time.AfterFunc(time.Millisecond, func() {
+					obs.Delete(keyInt)
+					l.Broadcast(312)
+				})
+			}
+			if l.Wait().(int) != 312 {
+				b.Fail()
+			}
+
+			i++
+		}
+	})
+}
+
+func BenchmarkThreadsGetInt(b *testing.B) {
+	obs := NewIntListeners()
+	for i := 0; i < steps; i++ {
+		obs.GetOrCreate(i)
+	}
+
+	b.SetParallelism(benchWorks)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if _, found := obs.GetOrCreate(i % steps); !found {
+				b.Fail()
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkThreadsGetDenseInt(b *testing.B) {
+	obs := NewDenseIntListeners(steps)
+	for i := 0; i < steps; i++ {
+		obs.GetOrCreate(i)
+	}
+
+	b.SetParallelism(benchWorks)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if _, found := obs.GetOrCreate(i % steps); !found {
+				b.Fail()
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkThreadsGetStatic(b *testing.B) {
+	backends := []struct {
+		name string
+		opts []Option
+	}{
+		{"Default", nil},
+		{"CopyOnWrite", []Option{WithCopyOnWrite()}},
+	}
+	for _, be := range backends {
+		b.Run("Listeners/"+be.name, func(b *testing.B) {
+			obs := NewListenersWith(be.opts...)
+			boxed := make([]interface{}, steps)
+			for i := range boxed {
+				boxed[i] = keys[i]
+				obs.GetOrCreate(boxed[i])
+			}
+			benchmarkGetStatic(b, func(i int) bool {
+				_, found := obs.Get(boxed[i])
+				return found
+			})
+		})
+		b.Run("StringListeners/"+be.name, func(b *testing.B) {
+			obs := NewStringListenersWith(be.opts...)
+			for i := 0; i < steps; i++ {
+				obs.GetOrCreate(keys[i])
+			}
+			benchmarkGetStatic(b, func(i int) bool {
+				_, found := obs.Get(keys[i])
+				return found
+			})
+		})
+		b.Run("IntListeners/"+be.name, func(b *testing.B) {
+			obs := NewIntListenersWith(be.opts...)
+			for i := 0; i < steps; i++ {
+				obs.GetOrCreate(i)
+			}
+			benchmarkGetStatic(b, func(i int) bool {
+				_, found := obs.Get(i)
+				return found
+			})
+		})
+	}
+}
+
+func benchmarkGetStatic(b *testing.B, get func(i int) bool) {
+	b.SetParallelism(benchWorks)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if !get(i % steps) {
+				b.Fail()
+			}
+			i++
+		}
+	})
+}
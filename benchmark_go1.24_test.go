@@ -0,0 +1,79 @@
+//go:build go1.24
+// +build go1.24
+
+package listener
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// benchmarkChurn runs create -> broadcast -> delete cycles on keys spread
+// over all workers, which is the pattern the default backends handle worst.
+func benchmarkChurn(b *testing.B, cycle func(i int)) {
+	var worker uint32
+
+	b.SetParallelism(benchWorks / 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(atomic.AddUint32(&worker, 1)) * 7919
+		for pb.Next() {
+			cycle(i % items)
+			i++
+		}
+	})
+}
+
+func BenchmarkChurn(b *testing.B) {
+	boxed := make([]interface{}, items)
+	for i, key := range keys {
+		boxed[i] = key
+	}
+
+	backends := []struct {
+		name string
+		opts []Option
+	}{
+		{"Default", nil},
+		{"Shards", []Option{WithShards(0)}},
+		{"Pool", []Option{WithOncePool()}},
+		{"Once", []Option{WithCreater(NewListenerOnce)}},
+		{"ShardsPool", []Option{WithShards(0), WithOncePool()}},
+	}
+	for _, be := range backends {
+		b.Run("Listeners/"+be.name, func(b *testing.B) {
+			ls := NewListenersWith(be.opts...)
+			benchmarkChurn(b, func(i int) {
+				li, _ := ls.GetOrCreate(boxed[i])
+				li.Broadcast(nil)
+				ls.Delete(boxed[i])
+			})
+		})
+		b.Run("StringListeners/"+be.name, func(b *testing.B) {
+			ls := NewStringListenersWith(be.opts...)
+			benchmarkChurn(b, func(i int) {
+				li, _ := ls.GetOrCreate(keys[i])
+				li.Broadcast(nil)
+				ls.Delete(keys[i])
+			})
+		})
+		b.Run("IntListeners/"+be.name, func(b *testing.B) {
+			ls := NewIntListenersWith(be.opts...)
+			benchmarkChurn(b, func(i int) {
+				li, _ := ls.GetOrCreate(i)
+				li.Broadcast(nil)
+				ls.Delete(i)
+			})
+		})
+		b.Run("Get/"+be.name, func(b *testing.B) {
+			ls := NewStringListenersWith(be.opts...)
+			for _, key := range keys {
+				ls.GetOrCreate(key)
+			}
+			benchmarkChurn(b, func(i int) {
+				ls.Get(keys[i])
+			})
+		})
+	}
+}
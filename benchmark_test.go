@@ -1,7 +1,10 @@
 package listener
 
 import (
+	"fmt"
 	"math/rand"
+	"runtime"
+	"sort"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -512,3 +515,163 @@ func BenchmarkThreadsOnceInt(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkGetOrCreateHit(b *testing.B) {
+	var key interface{} = keys[0]
+	obs := NewListeners()
+	sobs := NewStringListeners()
+	iobs := NewIntListeners()
+	obs.GetOrCreate(key)
+	sobs.GetOrCreate(keys[0])
+	iobs.GetOrCreate(0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		obs.GetOrCreate(key)
+		sobs.GetOrCreate(keys[0])
+		iobs.GetOrCreate(0)
+	}
+}
+
+func BenchmarkBroadcast(b *testing.B) {
+	p := new(int)
+	n := 7
+
+	for _, bc := range []struct {
+		name string
+		f    func(l Listener)
+	}{
+		{"Pointer", func(l Listener) { l.Broadcast(p) }},
+		{"Small", func(l Listener) { l.Broadcast(n) }},
+		{"Const", func(l Listener) { l.Broadcast(312) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			l := NewListener()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				bc.f(l)
+				if _, ok := l.Receive(); !ok {
+					b.Fail()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkThreadsBroadcast(b *testing.B) {
+	l := NewListener()
+	p := new(int)
+
+	b.SetParallelism(benchWorks)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if i%4 == 0 {
+				l.Broadcast(p)
+			} else if l.Wait() != p {
+				b.Fail()
+			}
+			i++
+		}
+	})
+}
+
+func benchmarkWakeLatency(b *testing.B, creater func() Listener) {
+	lat := make([]time.Duration, 0, b.N)
+	done := make(chan time.Time)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l := creater()
+		go func() {
+			l.Wait()
+			done <- time.Now()
+		}()
+		for l.(Inspector).Waiters() == 0 {
+			runtime.Gosched()
+		}
+
+		start := time.Now()
+		l.Broadcast(i)
+		lat = append(lat, (<-done).Sub(start))
+	}
+
+	b.StopTimer()
+	sort.Slice(lat, func(i, j int) bool {
+		return lat[i] < lat[j]
+	})
+	for _, p := range []int{50, 90, 99} {
+		b.ReportMetric(float64(lat[(len(lat)-1)*p/100].Nanoseconds()), fmt.Sprintf("p%d-ns", p))
+	}
+}
+
+func BenchmarkWakeLatency(b *testing.B) {
+	b.Run("Park", func(b *testing.B) {
+		benchmarkWakeLatency(b, NewListener)
+	})
+	b.Run("Spin", func(b *testing.B) {
+		benchmarkWakeLatency(b, func() Listener {
+			return NewListenerWith(WithSpin(64))
+		})
+	})
+	b.Run("Once", func(b *testing.B) {
+		benchmarkWakeLatency(b, NewListenerOnce)
+	})
+}
+
+// BenchmarkBroadcastAfter schedules b.N pending timeouts and cancels them
+// all at the end, like one timeout per outstanding request.
+func BenchmarkBroadcastAfter(b *testing.B) {
+	b.Run("AfterFunc", func(b *testing.B) {
+		li := NewListener()
+		timers := make([]*time.Timer, 0, b.N)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			timers = append(timers, time.AfterFunc(time.Hour+time.Duration(i), func() {
+				li.Broadcast(312)
+			}))
+		}
+		b.StopTimer()
+		for _, t := range timers {
+			t.Stop()
+		}
+	})
+	b.Run("Wheel", func(b *testing.B) {
+		obs := NewIntListeners()
+		li, _ := obs.GetOrCreate(0)
+		w := NewTimerWheel(time.Millisecond, nil)
+		timers := make([]Timer, 0, b.N)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			timers = append(timers, w.broadcastAfter(li, time.Hour+time.Duration(i), 312))
+		}
+		b.StopTimer()
+		for _, t := range timers {
+			t.Stop()
+		}
+	})
+	b.Run("Registry", func(b *testing.B) {
+		obs := NewIntListenersWith(WithTimerWheel(NewTimerWheel(time.Millisecond, nil)))
+		timers := make([]Timer, 0, b.N)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			timers = append(timers, obs.BroadcastAfter(i%steps, time.Hour, 312))
+		}
+		b.StopTimer()
+		for _, t := range timers {
+			t.Stop()
+		}
+	})
+}
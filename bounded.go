@@ -0,0 +1,229 @@
+//go:build go1.18
+// +build go1.18
+
+package listener
+
+import (
+	"sync"
+)
+
+type (
+	store[K comparable] interface {
+		load(key K) (Listener, bool)
+		loadOrCreate(key K, c *config) (Listener, bool)
+		loadOrStore(key K, li Listener) (Listener, bool)
+		swap(key K, li Listener) Listener
+		loadAndDelete(key K) (Listener, bool)
+		rangeAll(f func(key K, li Listener) bool)
+		len() int
+	}
+
+	boundedStore[K comparable] struct {
+		store[K]
+		cfg    *config
+		max    int
+		n      int // keys in the store, len() may be O(n)
+		opts   EvictionOptions
+		policy EvictionPolicy
+		mu     sync.Mutex
+	}
+
+	// dropper is a store that removes keys on its own. Every key it
+	// drops, or that went away without Delete reporting it, is passed
+	// to f once.
+	dropper[K comparable] interface {
+		onDrop(f func(key K))
+	}
+
+	eviction struct {
+		key interface{}
+		li  Listener
+	}
+)
+
+var (
+	_ anyStore    = &boundedStore[interface{}]{}
+	_ intStore    = &boundedStore[int]{}
+	_ stringStore = &boundedStore[string]{}
+)
+
+// WithMaxEntries bounds the registry to max keys. Adding a key beyond the
+// limit evicts others as chosen by the policy: they are removed like with
+// Delete and then closed with ErrEvicted or handed to OnEvict. The key
+// being added and, unless EvictWaiting is set, keys with active waiters
+// are never evicted; the registry exceeds max while nothing else can go.
+// DenseIntListeners does not evict, it stops growing on its own instead:
+// keys from Cap on are out of range, so Cap rather than max bounds it.
+func WithMaxEntries(max int, opts ...EvictionOptions) Option {
+	var o EvictionOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+	if o.Policy == nil {
+		o.Policy = LRU
+	}
+
+	return func(c *config) {
+		c.backend.anyBound = func(s anyStore, c *config) anyStore {
+			return newBoundedStore[interface{}](s, c, max, o)
+		}
+		c.backend.intBound = func(s intStore, c *config) intStore {
+			return newBoundedStore[int](s, c, max, o)
+		}
+		c.backend.stringBound = func(s stringStore, c *config) stringStore {
+			return newBoundedStore[string](s, c, max, o)
+		}
+	}
+}
+
+func newBoundedStore[K comparable](s store[K], c *config, max int, o EvictionOptions) *boundedStore[K] {
+	if max < 1 {
+		max = 1
+	}
+
+	b := &boundedStore[K]{
+		store:  s,
+		cfg:    c,
+		max:    max,
+		opts:   o,
+		policy: o.Policy(),
+	}
+	if d, ok := s.(dropper[K]); ok {
+		d.onDrop(b.dropped)
+	}
+
+	return b
+}
+
+func (s *boundedStore[K]) dropped(key K) {
+	s.mu.Lock()
+	s.n--
+	if _, found := s.store.load(key); !found {
+		s.policy.Remove(key)
+	}
+	s.mu.Unlock()
+}
+
+func (s *boundedStore[K]) load(key K) (li Listener, found bool) {
+	if li, found = s.store.load(key); found {
+		s.mu.Lock()
+		s.policy.Access(key)
+		s.mu.Unlock()
+	}
+
+	return
+}
+
+func (s *boundedStore[K]) loadOrCreate(key K, c *config) (Listener, bool) {
+	return s.add(key, func() (Listener, bool) {
+		return s.store.loadOrCreate(key, c)
+	})
+}
+
+func (s *boundedStore[K]) loadOrStore(key K, li Listener) (Listener, bool) {
+	return s.add(key, func() (Listener, bool) {
+		return s.store.loadOrStore(key, li)
+	})
+}
+
+func (s *boundedStore[K]) add(key K, load func() (Listener, bool)) (li Listener, found bool) {
+	s.mu.Lock()
+	li, found = load()
+	if found {
+		s.policy.Access(key)
+		s.mu.Unlock()
+		return
+	}
+	s.n++
+	s.policy.Add(key, unwrap(li))
+	evicted := s.evict(key)
+	s.mu.Unlock()
+
+	s.evicted(evicted)
+
+	return
+}
+
+func (s *boundedStore[K]) swap(key K, li Listener) Listener {
+	s.mu.Lock()
+	old := s.store.swap(key, li)
+	if old == nil {
+		s.n++
+	}
+	s.policy.Add(key, unwrap(li))
+	evicted := s.evict(key)
+	s.mu.Unlock()
+
+	s.evicted(evicted)
+
+	return old
+}
+
+func (s *boundedStore[K]) loadAndDelete(key K) (li Listener, found bool) {
+	s.mu.Lock()
+	if li, found = s.store.loadAndDelete(key); found {
+		s.n--
+		s.policy.Remove(key)
+	}
+	s.mu.Unlock()
+
+	return
+}
+
+// evict makes room for the key just added, which is never a victim
+// itself. It must be called with s.mu held.
+func (s *boundedStore[K]) evict(added K) (evicted []eviction) {
+	for s.n > s.max {
+		victim, ok := s.victim(added, false)
+		if !ok && s.opts.EvictWaiting {
+			victim, ok = s.victim(added, true)
+		}
+		if !ok {
+			break
+		}
+
+		s.policy.Remove(victim.key)
+		evicted = append(evicted, victim)
+	}
+
+	return
+}
+
+func (s *boundedStore[K]) victim(added K, waiting bool) (victim eviction, ok bool) {
+	s.policy.Victims(func(key interface{}) bool {
+		if key.(K) == added {
+			return false
+		}
+		li, found := s.store.load(key.(K))
+		if !found {
+			return false
+		}
+		if in, isInspector := li.(Inspector); isInspector && in.Waiters() != 0 && !waiting {
+			return false
+		}
+
+		s.store.loadAndDelete(key.(K))
+		s.n--
+		victim, ok = eviction{key: key, li: li}, true
+		return true
+	})
+	return
+}
+
+func (s *boundedStore[K]) evicted(evicted []eviction) {
+	c := s.cfg
+	for _, e := range evicted {
+		c.metrics.Deleted()
+		if c.keyed {
+			c.deleted(e.key, e.li)
+		}
+		if s.opts.OnEvict != nil {
+			s.opts.OnEvict(e.key, e.li)
+		} else if cl, ok := e.li.(Closer); ok {
+			cl.Close(ErrEvicted)
+		}
+		if c.pooled {
+			c.release(e.li)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package listener
+
+import (
+	"time"
+)
+
+type (
+	Clock interface {
+		Now() time.Time
+	}
+
+	// TimerClock is a Clock that can also schedule callbacks. Clocks that
+	// only implement Clock get their callbacks from time.AfterFunc.
+	TimerClock interface {
+		Clock
+		AfterFunc(d time.Duration, f func()) Timer
+	}
+
+	// Timer cancels a scheduled callback, like time.Timer.
+	Timer interface {
+		Stop() bool
+	}
+
+	systemClock struct{}
+)
+
+var (
+	SystemClock Clock = systemClock{}
+
+	_ TimerClock = systemClock{}
+)
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func afterFunc(c Clock, d time.Duration, f func()) Timer {
+	if tc, ok := c.(TimerClock); ok {
+		return tc.AfterFunc(d, f)
+	}
+
+	return time.AfterFunc(d, f)
+}
@@ -0,0 +1,194 @@
+// Command listenerctl inspects and manipulates listener registries served
+// by the debughttp handler.
+//
+//	listenerctl [-url URL] list [-registry R] [-state S] [-filter SUBSTR] [-min-waiters N] [-sort key|age|waiters|broadcasts] [-json]
+//	listenerctl [-url URL] broadcast -registry R -key K [-key-type T] -value V
+//	listenerctl [-url URL] close -registry R -key K [-key-type T] [-error MSG]
+//
+// Actions need a handler created with debughttp.HandlerOptions.AllowActions.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jenchik/listener/debughttp"
+)
+
+var (
+	client = &http.Client{Timeout: 10 * time.Second}
+)
+
+func main() {
+	base := flag.String("url", "http://localhost:6060/debug/listeners", "debug handler URL")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "list":
+		err = list(*base, args)
+	case "broadcast", "close":
+		err = action(*base, cmd, args)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "listenerctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `usage: listenerctl [-url URL] <command> [flags]
+
+commands:
+  list       show registered keys
+  broadcast  broadcast a value to a key
+  close      close a key
+
+`)
+	flag.PrintDefaults()
+}
+
+func list(base string, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	registry := fs.String("registry", "", "show only this registry")
+	state := fs.String("state", "", "show only keys in this state (pending, fired, closed)")
+	filter := fs.String("filter", "", "show only keys containing this substring")
+	minWaiters := fs.Int("min-waiters", 0, "show only keys with at least this many waiters")
+	sortBy := fs.String("sort", "key", "sort by key, age, waiters or broadcasts")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Parse(args)
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+
+	res, err := client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return status(res)
+	}
+
+	var s debughttp.Snapshot
+	if err = json.NewDecoder(res.Body).Decode(&s); err != nil {
+		return err
+	}
+
+	keys := s.Keys[:0]
+	for _, k := range s.Keys {
+		if (*registry == "" || k.Registry == *registry) &&
+			(*state == "" || k.State == *state) &&
+			strings.Contains(k.Key, *filter) &&
+			k.Waiters >= *minWaiters {
+			keys = append(keys, k)
+		}
+	}
+
+	var less func(a, b debughttp.KeyInfo) bool
+	switch *sortBy {
+	case "key":
+		less = func(a, b debughttp.KeyInfo) bool { return a.Key < b.Key }
+	case "age":
+		less = func(a, b debughttp.KeyInfo) bool { return a.Age > b.Age }
+	case "waiters":
+		less = func(a, b debughttp.KeyInfo) bool { return a.Waiters > b.Waiters }
+	case "broadcasts":
+		less = func(a, b debughttp.KeyInfo) bool { return a.Broadcasts > b.Broadcasts }
+	default:
+		return fmt.Errorf("unknown sort field %q", *sortBy)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return less(keys[i], keys[j])
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(debughttp.Snapshot{Keys: keys})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REGISTRY\tKEY\tSTATE\tVALUE TYPE\tWAITERS\tBROADCASTS\tAGE")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n",
+			k.Registry, k.Key, k.State, k.ValueType, k.Waiters, k.Broadcasts,
+			time.Duration(k.Age).Round(time.Millisecond))
+	}
+
+	return w.Flush()
+}
+
+func action(base, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	registry := fs.String("registry", "", "registry name")
+	key := fs.String("key", "", "key")
+	keyType := fs.String("key-type", "", "Go type of the key, as shown by list -json")
+	value := fs.String("value", "", "value to broadcast, decoded as JSON when possible")
+	msg := fs.String("error", "", "error message waiters are closed with")
+	fs.Parse(args)
+
+	if *registry == "" || *key == "" {
+		return fmt.Errorf("%s: -registry and -key are required", cmd)
+	}
+
+	form := url.Values{
+		"action":   {cmd},
+		"registry": {*registry},
+		"key":      {*key},
+	}
+	if *keyType != "" {
+		form.Set("key_type", *keyType)
+	}
+	if cmd == "broadcast" {
+		form.Set("value", *value)
+	} else if *msg != "" {
+		form.Set("error", *msg)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, base, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(debughttp.ActionHeader, cmd)
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return status(res)
+	}
+
+	return nil
+}
+
+func status(res *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+	return fmt.Errorf("%s: %s", res.Status, strings.TrimSpace(string(b)))
+}
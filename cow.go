@@ -0,0 +1,126 @@
+//go:build go1.19
+// +build go1.19
+
+package listener
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	cowStore[K comparable] struct {
+		snap atomic.Pointer[map[K]Listener]
+		mu   sync.Mutex
+	}
+)
+
+var (
+	_ anyStore    = &cowStore[interface{}]{}
+	_ intStore    = &cowStore[int]{}
+	_ stringStore = &cowStore[string]{}
+)
+
+// WithCopyOnWrite selects a registry backend for key sets that are
+// populated once and then mostly read: Get, GetOrCreate hits, Len and
+// Range do a single atomic load of an immutable snapshot, while every
+// write copies the whole map.
+func WithCopyOnWrite() Option {
+	return func(c *config) {
+		c.backend.anyStore = func(*config) anyStore {
+			return newCowStore[interface{}]()
+		}
+		c.backend.intStore = func(*config) intStore {
+			return newCowStore[int]()
+		}
+		c.backend.stringStore = func(*config) stringStore {
+			return newCowStore[string]()
+		}
+	}
+}
+
+func newCowStore[K comparable]() *cowStore[K] {
+	s := &cowStore[K]{}
+	m := make(map[K]Listener)
+	s.snap.Store(&m)
+
+	return s
+}
+
+func (s *cowStore[K]) load(key K) (li Listener, found bool) {
+	li, found = (*s.snap.Load())[key]
+	return
+}
+
+// update must be called with s.mu held.
+func (s *cowStore[K]) update(f func(m map[K]Listener)) {
+	old := *s.snap.Load()
+	m := make(map[K]Listener, len(old)+1)
+	for key, li := range old {
+		m[key] = li
+	}
+	f(m)
+	s.snap.Store(&m)
+}
+
+func (s *cowStore[K]) loadOrCreate(key K, c *config) (li Listener, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if li, found = s.load(key); found {
+		return
+	}
+	li = c.newListener(key)
+	s.update(func(m map[K]Listener) {
+		m[key] = li
+	})
+
+	return
+}
+
+func (s *cowStore[K]) loadOrStore(key K, li Listener) (Listener, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, found := s.load(key); found {
+		return old, true
+	}
+	s.update(func(m map[K]Listener) {
+		m[key] = li
+	})
+
+	return li, false
+}
+
+func (s *cowStore[K]) swap(key K, li Listener) (old Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, _ = s.load(key)
+	s.update(func(m map[K]Listener) {
+		m[key] = li
+	})
+
+	return
+}
+
+func (s *cowStore[K]) loadAndDelete(key K) (li Listener, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if li, found = s.load(key); found {
+		s.update(func(m map[K]Listener) {
+			delete(m, key)
+		})
+	}
+
+	return
+}
+
+func (s *cowStore[K]) rangeAll(f func(key K, li Listener) bool) {
+	for key, li := range *s.snap.Load() {
+		if !f(key, li) {
+			break
+		}
+	}
+}
+
+func (s *cowStore[K]) len() int {
+	return len(*s.snap.Load())
+}
@@ -0,0 +1,73 @@
+package listener
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	DeadlineOptions struct {
+		// Default is what the listener resolves to at the deadline;
+		// context.DeadlineExceeded if nil.
+		Default interface{}
+		// Wheel runs the deadline timer; the shared default wheel if nil.
+		Wheel *TimerWheel
+	}
+
+	// deadlineListener is a listenerOnce that resolves itself once its
+	// timer fires first.
+	deadlineListener struct {
+		*listenerOnce
+		timer Timer
+	}
+)
+
+var (
+	_ Listener  = &deadlineListener{}
+	_ Inspector = &deadlineListener{}
+	_ Closer    = &deadlineListener{}
+)
+
+// NewDeadlineListener returns a listener that takes the first broadcast,
+// like NewListenerOnce, or resolves on its own after timeout. Its timer
+// is released as soon as a value arrives.
+func NewDeadlineListener(timeout time.Duration, opts ...DeadlineOptions) Listener {
+	var o DeadlineOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+	if o.Default == nil {
+		o.Default = context.DeadlineExceeded
+	}
+	if o.Wheel == nil {
+		o.Wheel = defaultWheel
+	}
+
+	l := &deadlineListener{
+		listenerOnce: newListenerOnce(),
+	}
+	lo := l.listenerOnce
+	l.timer = o.Wheel.AfterFunc(timeout, func() {
+		lo.resolve(o.Default)
+	})
+
+	return l
+}
+
+// DeadlineCreater returns a creater for registries that makes deadline
+// listeners.
+func DeadlineCreater(timeout time.Duration, opts ...DeadlineOptions) func() Listener {
+	return func() Listener {
+		return NewDeadlineListener(timeout, opts...)
+	}
+}
+
+func (l *deadlineListener) Broadcast(value interface{}) {
+	l.listenerOnce.Broadcast(value)
+	l.timer.Stop()
+}
+
+func (l *deadlineListener) Close(err error) {
+	l.listenerOnce.Close(err)
+	l.timer.Stop()
+}
@@ -0,0 +1,410 @@
+// Package debughttp serves the state of live listener registries over HTTP.
+//
+// The handler is meant to be mounted next to net/http/pprof:
+//
+//	h := debughttp.NewHandler()
+//	h.RegisterString("requests", requests)
+//	http.Handle("/debug/listeners", h)
+//
+// GET renders an HTML table, or JSON with ?format=json. When enabled with
+// HandlerOptions.AllowActions, POST with
+// ?action=broadcast&registry=R&key=K&value=V broadcasts V (decoded as JSON
+// when possible) to an existing key, and ?action=close&registry=R&key=K
+// closes it with an optional ?error= message. Actions must carry the
+// X-Listener-Action header, which browsers do not send cross-site without
+// a preflight. Keys of a Listeners registry may be qualified with
+// ?key_type=T, as reported in the key_type field of the snapshot.
+package debughttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenchik/listener"
+)
+
+type (
+	Handler struct {
+		mu      sync.RWMutex
+		sources map[string]source
+		actions bool
+	}
+
+	HandlerOptions struct {
+		// AllowActions enables the broadcast and close actions. They are
+		// refused with 403 Forbidden otherwise.
+		AllowActions bool
+	}
+
+	Snapshot struct {
+		Keys []KeyInfo `json:"keys"`
+	}
+
+	KeyInfo struct {
+		Registry   string    `json:"registry"`
+		Key        string    `json:"key"`
+		KeyType    string    `json:"key_type"`
+		Type       string    `json:"type"`
+		State      string    `json:"state"`
+		ValueType  string    `json:"value_type,omitempty"`
+		Waiters    int       `json:"waiters"`
+		Broadcasts uint64    `json:"broadcasts"`
+		CreatedAt  time.Time `json:"created_at"`
+		FiredAt    time.Time `json:"fired_at"`
+		Age        Duration  `json:"age"`
+	}
+
+	// Duration is encoded in JSON as a time.Duration string.
+	Duration time.Duration
+
+	source struct {
+		kind   string
+		ranger func(f func(key interface{}, li listener.Listener) bool)
+		lookup func(key, keyType string) (listener.Listener, error)
+	}
+)
+
+const (
+	// ActionHeader must be set on POST requests for actions to run.
+	ActionHeader = "X-Listener-Action"
+)
+
+var (
+	_ http.Handler = &Handler{}
+
+	errUnknownRegistry = errors.New("unknown registry")
+	errUnknownKey      = errors.New("unknown key")
+	errAmbiguousKey    = errors.New("ambiguous key, set key_type")
+	errActions         = errors.New("actions are disabled")
+	errActionHeader    = errors.New("missing " + ActionHeader + " header")
+
+	keyTypes = map[string]reflect.Type{}
+)
+
+func init() {
+	for _, v := range []interface{}{
+		"", false,
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0), uintptr(0),
+		float32(0), float64(0),
+	} {
+		t := reflect.TypeOf(v)
+		keyTypes[t.String()] = t
+	}
+}
+
+func NewHandler(opts ...HandlerOptions) *Handler {
+	var o HandlerOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+
+	return &Handler{
+		sources: make(map[string]source),
+		actions: o.AllowActions,
+	}
+}
+
+func (h *Handler) Register(name string, ls *listener.Listeners) {
+	h.register(name, source{
+		kind:   "Listeners",
+		ranger: ls.Range,
+		lookup: func(key, keyType string) (li listener.Listener, err error) {
+			if k, ok := parseKey(key, keyType); ok {
+				if li, ok = ls.Get(k); ok {
+					return li, nil
+				}
+				return nil, errUnknownKey
+			}
+
+			// keys of other types can only be matched by their text
+			err = errUnknownKey
+			ls.Range(func(k interface{}, l listener.Listener) bool {
+				if fmt.Sprint(k) != key || (keyType != "" && fmt.Sprintf("%T", k) != keyType) {
+					return true
+				}
+				if li != nil {
+					li, err = nil, errAmbiguousKey
+					return false
+				}
+				li, err = l, nil
+				return true
+			})
+			return
+		},
+	})
+}
+
+func (h *Handler) RegisterInt(name string, ls *listener.IntListeners) {
+	h.register(name, source{
+		kind: "IntListeners",
+		ranger: func(f func(key interface{}, li listener.Listener) bool) {
+			ls.Range(func(key int, li listener.Listener) bool {
+				return f(key, li)
+			})
+		},
+		lookup: func(key, keyType string) (listener.Listener, error) {
+			if keyType != "" && keyType != "int" {
+				return nil, errUnknownKey
+			}
+			n, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, errUnknownKey
+			}
+			return found(ls.Get(n))
+		},
+	})
+}
+
+func (h *Handler) RegisterString(name string, ls *listener.StringListeners) {
+	h.register(name, source{
+		kind: "StringListeners",
+		ranger: func(f func(key interface{}, li listener.Listener) bool) {
+			ls.Range(func(key string, li listener.Listener) bool {
+				return f(key, li)
+			})
+		},
+		lookup: func(key, keyType string) (listener.Listener, error) {
+			if keyType != "" && keyType != "string" {
+				return nil, errUnknownKey
+			}
+			return found(ls.Get(key))
+		},
+	})
+}
+
+func found(li listener.Listener, ok bool) (listener.Listener, error) {
+	if !ok {
+		return nil, errUnknownKey
+	}
+
+	return li, nil
+}
+
+// parseKey rebuilds a key of a basic type from its text, so that it can
+// be looked up directly.
+func parseKey(key, keyType string) (interface{}, bool) {
+	t, ok := keyTypes[keyType]
+	if !ok {
+		return nil, false
+	}
+
+	v := reflect.New(t).Elem()
+	var err error
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(key)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(key)
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(key, 10, t.Bits())
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		n, err = strconv.ParseUint(key, 10, t.Bits())
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(key, t.Bits())
+		v.SetFloat(f)
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	return v.Interface(), true
+}
+
+func (h *Handler) Unregister(name string) {
+	h.mu.Lock()
+	delete(h.sources, name)
+	h.mu.Unlock()
+}
+
+func (h *Handler) register(name string, s source) {
+	h.mu.Lock()
+	h.sources[name] = s
+	h.mu.Unlock()
+}
+
+func (h *Handler) Snapshot() Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	now := time.Now()
+	s := Snapshot{
+		Keys: []KeyInfo{},
+	}
+	for name, src := range h.sources {
+		src.ranger(func(key interface{}, li listener.Listener) bool {
+			s.Keys = append(s.Keys, keyInfo(name, key, li, now))
+			return true
+		})
+	}
+	sort.Slice(s.Keys, func(i, j int) bool {
+		if s.Keys[i].Registry != s.Keys[j].Registry {
+			return s.Keys[i].Registry < s.Keys[j].Registry
+		}
+		return s.Keys[i].Key < s.Keys[j].Key
+	})
+
+	return s
+}
+
+func keyInfo(registry string, key interface{}, li listener.Listener, now time.Time) KeyInfo {
+	ki := KeyInfo{
+		Registry: registry,
+		Key:      fmt.Sprint(key),
+		KeyType:  fmt.Sprintf("%T", key),
+		Type:     fmt.Sprintf("%T", unwrap(li)),
+		State:    "unknown",
+	}
+	if value, ok := li.Receive(); ok {
+		ki.ValueType = fmt.Sprintf("%T", value)
+	}
+	if in, ok := li.(listener.Inspector); ok {
+		ki.State = in.State().String()
+		ki.Waiters = in.Waiters()
+		ki.Broadcasts = in.BroadcastCount()
+		ki.CreatedAt = in.CreatedAt()
+		ki.FiredAt = in.FiredAt()
+		if !ki.CreatedAt.IsZero() {
+			ki.Age = Duration(now.Sub(ki.CreatedAt))
+		}
+	}
+
+	return ki
+}
+
+// unwrap returns the listener behind the wrappers a registry adds for
+// metrics, hooks and the like.
+func unwrap(li listener.Listener) listener.Listener {
+	for {
+		u, ok := li.(interface {
+			Unwrap() listener.Listener
+		})
+		if !ok {
+			return li
+		}
+		li = u.Unwrap()
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s := h.Snapshot()
+		if r.FormValue("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(s)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page.Execute(w, s)
+	case http.MethodPost:
+		if err := h.action(r); err != nil {
+			code := http.StatusBadRequest
+			switch err {
+			case errUnknownRegistry, errUnknownKey:
+				code = http.StatusNotFound
+			case errActions, errActionHeader:
+				code = http.StatusForbidden
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) action(r *http.Request) error {
+	if !h.actions {
+		return errActions
+	}
+	if r.Header.Get(ActionHeader) == "" {
+		return errActionHeader
+	}
+
+	h.mu.RLock()
+	src, found := h.sources[r.FormValue("registry")]
+	h.mu.RUnlock()
+	if !found {
+		return errUnknownRegistry
+	}
+
+	li, err := src.lookup(r.FormValue("key"), r.FormValue("key_type"))
+	if err != nil {
+		return err
+	}
+
+	switch action := r.FormValue("action"); action {
+	case "broadcast":
+		raw := r.FormValue("value")
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		return listener.TryBroadcast(li, value)
+	case "close":
+		c, ok := li.(listener.Closer)
+		if !ok {
+			return fmt.Errorf("%T can not be closed", li)
+		}
+		var err error
+		if msg := r.FormValue("error"); msg != "" {
+			err = errors.New(msg)
+		}
+		c.Close(err)
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+var page = template.Must(template.New("listeners").Parse(`<!DOCTYPE html>
+<html>
+<head><title>listeners</title></head>
+<body>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>registry</th><th>key</th><th>type</th><th>state</th><th>value type</th><th>waiters</th><th>broadcasts</th><th>age</th><th>fired at</th></tr>
+{{range .Keys}}<tr><td>{{.Registry}}</td><td>{{.Key}} <small>{{.KeyType}}</small></td><td>{{.Type}}</td><td>{{.State}}</td><td>{{.ValueType}}</td><td>{{.Waiters}}</td><td>{{.Broadcasts}}</td><td>{{.Age}}</td><td>{{if not .FiredAt.IsZero}}{{.FiredAt.Format "2006-01-02T15:04:05.000Z07:00"}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
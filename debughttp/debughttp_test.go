@@ -0,0 +1,138 @@
+package debughttp_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jenchik/listener"
+	"github.com/jenchik/listener/debughttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	ls := listener.NewListeners()
+	ils := listener.NewIntListenersWith(listener.WithCreater(listener.NewListenerOnce), listener.WithInterceptors(listener.InterceptorFuncs{}))
+	sls := listener.NewStringListeners()
+
+	ls.GetOrCreate("any")
+	li, _ := ils.GetOrCreate(7)
+	li.Broadcast("done")
+	sls.GetOrCreate("request")
+
+	h := debughttp.NewHandler(debughttp.HandlerOptions{AllowActions: true})
+	h.Register("any", ls)
+	h.RegisterInt("ints", ils)
+	h.RegisterString("strings", sls)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "?format=json")
+	assert.NoError(t, err)
+	var s debughttp.Snapshot
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&s))
+	res.Body.Close()
+
+	assert.Len(t, s.Keys, 3)
+	assert.Equal(t, "any", s.Keys[0].Registry)
+	assert.Equal(t, "pending", s.Keys[0].State)
+	assert.Equal(t, "ints", s.Keys[1].Registry)
+	assert.Equal(t, "7", s.Keys[1].Key)
+	assert.Equal(t, "int", s.Keys[1].KeyType)
+	assert.Equal(t, fmt.Sprintf("%T", listener.NewListenerOnce()), s.Keys[1].Type)
+	assert.Equal(t, "fired", s.Keys[1].State)
+	assert.Equal(t, "string", s.Keys[1].ValueType)
+	assert.Equal(t, uint64(1), s.Keys[1].Broadcasts)
+	assert.Equal(t, "request", s.Keys[2].Key)
+
+	res, err = http.Get(srv.URL)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(res.Header.Get("Content-Type"), "text/html"))
+	res.Body.Close()
+
+	res, err = http.PostForm(srv.URL, url.Values{
+		"action": {"broadcast"}, "registry": {"strings"}, "key": {"request"}, "value": {"1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res.Body.Close()
+	li, _ = sls.Get("request")
+	_, ok := li.Receive()
+	assert.False(t, ok)
+
+	res, err = post(srv.URL, url.Values{
+		"action": {"broadcast"}, "registry": {"strings"}, "key": {"request"}, "value": {`{"ok":true}`},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	li, _ = sls.Get("request")
+	assert.Equal(t, map[string]interface{}{"ok": true}, li.Wait())
+
+	res, err = post(srv.URL, url.Values{
+		"action": {"close"}, "registry": {"any"}, "key": {"any"}, "error": {"incident"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	li, _ = ls.Get("any")
+	assert.Equal(t, listener.StateClosed, li.(listener.Inspector).State())
+	assert.EqualError(t, li.Wait().(error), "incident")
+
+	res, err = post(srv.URL, url.Values{
+		"action": {"close"}, "registry": {"ints"}, "key": {"8"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestHandlerKeyTypes(t *testing.T) {
+	ls := listener.NewListeners()
+	ints, _ := ls.GetOrCreate(1)
+	strs, _ := ls.GetOrCreate("1")
+
+	h := debughttp.NewHandler(debughttp.HandlerOptions{AllowActions: true})
+	h.Register("any", ls)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := post(srv.URL, url.Values{"action": {"close"}, "registry": {"any"}, "key": {"1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = post(srv.URL, url.Values{"action": {"close"}, "registry": {"any"}, "key": {"1"}, "key_type": {"int"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	assert.Equal(t, listener.StateClosed, ints.(listener.Inspector).State())
+	assert.Equal(t, listener.StatePending, strs.(listener.Inspector).State())
+
+	res, err = post(srv.URL, url.Values{"action": {"close"}, "registry": {"any"}, "key": {"2"}, "key_type": {"int"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	h = debughttp.NewHandler()
+	h.Register("any", ls)
+	srv2 := httptest.NewServer(h)
+	defer srv2.Close()
+	res, err = post(srv2.URL, url.Values{"action": {"close"}, "registry": {"any"}, "key": {"1"}, "key_type": {"string"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, listener.StatePending, strs.(listener.Inspector).State())
+}
+
+func post(u string, form url.Values) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(debughttp.ActionHeader, form.Get("action"))
+	res, err := http.DefaultClient.Do(req)
+	if err == nil {
+		res.Body.Close()
+	}
+
+	return res, err
+}
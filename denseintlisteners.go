@@ -0,0 +1,261 @@
+//go:build go1.19
+// +build go1.19
+
+package listener
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// DenseIntListeners is an IntListeners for small non-negative keys,
+	// stored by index. Get and GetOrCreate are lock-free; the table only
+	// takes a lock to grow. GetOrCreate grows the table for keys below
+	// MaxDenseGrow, unless WithMaxEntries is set, Grow for any size. Other
+	// keys, including negative ones, are out of range: they are never
+	// found, GetOrCreate, BroadcastAfter and BroadcastAt panic with
+	// ErrKeyRange, TryGetOrCreate and WaitUntilKey return it and Put drops
+	// them.
+	DenseIntListeners struct {
+		config
+		table atomic.Pointer[denseTable]
+		n     int64
+		fixed bool // only Grow grows the table
+		mu    sync.Mutex
+	}
+
+	denseTable struct {
+		// cells are shared between the table versions, so that growing
+		// never loses an update made through an older table.
+		cells []*denseCell
+	}
+
+	denseCell struct {
+		p atomic.Pointer[denseEntry]
+	}
+
+	denseEntry struct {
+		li Listener
+	}
+)
+
+const (
+	MaxDenseGrow = 1 << 20
+)
+
+var (
+	ErrKeyRange = errors.New("listener: key out of range")
+)
+
+func NewDenseIntListeners(size int, creater ...func() Listener) *DenseIntListeners {
+	return NewDenseIntListenersWith(size, firstCreater(creater))
+}
+
+func NewDenseIntListenersWith(size int, opts ...Option) *DenseIntListeners {
+	l := &DenseIntListeners{
+		config: newConfig(opts),
+	}
+	l.fixed = l.backend.intBound != nil
+	l.table.Store(&denseTable{})
+	l.Grow(size)
+
+	return l
+}
+
+func (l *DenseIntListeners) Cap() int {
+	return len(l.table.Load().cells)
+}
+
+// Grow makes room for keys up to n-1.
+func (l *DenseIntListeners) Grow(n int) {
+	if n <= l.Cap() {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	old := l.table.Load()
+	if n <= len(old.cells) {
+		return
+	}
+
+	cells := make([]*denseCell, n)
+	copy(cells, old.cells)
+	chunk := make([]denseCell, n-len(old.cells))
+	for i := range chunk {
+		cells[len(old.cells)+i] = &chunk[i]
+	}
+	l.table.Store(&denseTable{cells: cells})
+}
+
+// cell returns nil for keys out of range.
+func (l *DenseIntListeners) cell(key int) *denseCell {
+	if key < 0 {
+		return nil
+	}
+
+	t := l.table.Load()
+	if key < len(t.cells) {
+		return t.cells[key]
+	}
+	if key >= MaxDenseGrow || l.fixed {
+		return nil
+	}
+
+	n := 2 * len(t.cells)
+	if n <= key {
+		n = key + 1
+	}
+	l.Grow(n)
+
+	return l.table.Load().cells[key]
+}
+
+// GetOrCreate panics with ErrKeyRange for keys out of range.
+func (l *DenseIntListeners) GetOrCreate(key int) (Listener, bool) {
+	li, found, err := l.TryGetOrCreate(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return li, found
+}
+
+// TryGetOrCreate is GetOrCreate that fails with ErrKeyRange for keys out
+// of range.
+func (l *DenseIntListeners) TryGetOrCreate(key int) (Listener, bool, error) {
+	c := l.cell(key)
+	if c == nil {
+		return nil, false, ErrKeyRange
+	}
+
+	var e *denseEntry
+	for {
+		if old := c.p.Load(); old != nil {
+			l.metrics.Hit()
+			if l.keyed {
+				l.accessed(key, old.li)
+			}
+			return old.li, true, nil
+		}
+
+		if e == nil {
+			e = &denseEntry{li: l.newListener(key)}
+		}
+		if c.p.CompareAndSwap(nil, e) {
+			atomic.AddInt64(&l.n, 1)
+			l.created(key, e.li)
+			return e.li, false, nil
+		}
+	}
+}
+
+func (l *DenseIntListeners) Get(key int) (Listener, bool) {
+	t := l.table.Load()
+	if key < 0 || key >= len(t.cells) {
+		return nil, false
+	}
+	if e := t.cells[key].p.Load(); e != nil {
+		return e.li, true
+	}
+
+	return nil, false
+}
+
+func (l *DenseIntListeners) GetReceiver(key int) (Receiver, bool) {
+	if li, found := l.Get(key); found {
+		return ReadOnly(li), true
+	}
+
+	return nil, false
+}
+
+func (l *DenseIntListeners) Len() int {
+	return int(atomic.LoadInt64(&l.n))
+}
+
+func (l *DenseIntListeners) Delete(key int) {
+	t := l.table.Load()
+	if key < 0 || key >= len(t.cells) {
+		return
+	}
+
+	if e := t.cells[key].p.Swap(nil); e != nil {
+		atomic.AddInt64(&l.n, -1)
+		l.metrics.Deleted()
+		if l.keyed {
+			l.deleted(key, e.li)
+		}
+		if l.pooled {
+			l.release(e.li)
+		}
+	}
+}
+
+func (l *DenseIntListeners) Put(key int, li Listener) Listener {
+	if li == nil {
+		old, _ := l.Get(key)
+		return old
+	}
+
+	c := l.cell(key)
+	if c == nil {
+		return nil
+	}
+
+	old := c.p.Swap(&denseEntry{li: li})
+	if old == nil {
+		atomic.AddInt64(&l.n, 1)
+		return nil
+	}
+
+	return old.li
+}
+
+func (l *DenseIntListeners) Range(f func(key int, li Listener) bool) {
+	for key, c := range l.table.Load().cells {
+		if e := c.p.Load(); e != nil {
+			if !f(key, e.li) {
+				break
+			}
+		}
+	}
+}
+
+// BroadcastAfter broadcasts value after d to the listener of key, created
+// now if missing. Stop the returned timer to cancel.
+func (l *DenseIntListeners) BroadcastAfter(key int, d time.Duration, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAfter(li, d, value)
+}
+
+// BroadcastAt is BroadcastAfter with an absolute time.
+func (l *DenseIntListeners) BroadcastAt(key int, t time.Time, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAt(li, t, value)
+}
+
+// WaitUntilKey is WaitUntil on the listener of key, created now if
+// missing.
+func (l *DenseIntListeners) WaitUntilKey(ctx context.Context, key int, pred func(value interface{}) bool) (interface{}, error) {
+	li, _, err := l.TryGetOrCreate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return WaitUntil(ctx, li, pred)
+}
+
+func (l *DenseIntListeners) Stats() (s Stats) {
+	l.Range(func(_ int, li Listener) bool {
+		s.add(li)
+		return true
+	})
+
+	return
+}
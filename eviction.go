@@ -0,0 +1,192 @@
+package listener
+
+import (
+	"container/list"
+	"errors"
+)
+
+type (
+	// EvictionPolicy orders the keys of a bounded registry for eviction.
+	// The registry serializes all calls.
+	EvictionPolicy interface {
+		Add(key interface{}, li Listener)
+		Access(key interface{})
+		Remove(key interface{})
+		// Victims calls evict with keys in eviction order until it
+		// returns true.
+		Victims(evict func(key interface{}) bool)
+	}
+
+	EvictionOptions struct {
+		// Policy makes the policy of each registry; LRU by default.
+		Policy func() EvictionPolicy
+		// OnEvict receives evicted listeners instead of them being
+		// closed with ErrEvicted.
+		OnEvict func(key interface{}, li Listener)
+		// EvictWaiting allows evicting keys with active waiters (which
+		// wake with ErrEvicted) when no other key can go. Otherwise the
+		// registry grows past its limit and shrinks on later inserts.
+		EvictWaiting bool
+	}
+
+	lruPolicy struct {
+		order *list.List // oldest first
+		elems map[interface{}]*list.Element
+	}
+
+	lruEntry struct {
+		key interface{}
+		li  Listener
+	}
+
+	firedFirstPolicy struct {
+		lruPolicy
+	}
+
+	lfuPolicy struct {
+		freqs *list.List // of *lfuBucket, lowest first
+		elems map[interface{}]*list.Element
+	}
+
+	lfuBucket struct {
+		freq  uint64
+		items *list.List // of *lfuEntry, oldest first
+	}
+
+	lfuEntry struct {
+		key    interface{}
+		bucket *list.Element
+	}
+)
+
+var (
+	ErrEvicted = errors.New("listener: evicted")
+
+	_ EvictionPolicy = &lruPolicy{}
+	_ EvictionPolicy = &firedFirstPolicy{}
+	_ EvictionPolicy = &lfuPolicy{}
+)
+
+// LRU evicts the least recently used key first.
+func LRU() EvictionPolicy {
+	return &lruPolicy{
+		order: list.New(),
+		elems: make(map[interface{}]*list.Element),
+	}
+}
+
+// LFU evicts the least frequently used key first, the oldest one among
+// equally used keys.
+func LFU() EvictionPolicy {
+	return &lfuPolicy{
+		freqs: list.New(),
+		elems: make(map[interface{}]*list.Element),
+	}
+}
+
+// FiredFirst evicts keys that have fired or been closed before pending
+// ones, each in LRU order.
+func FiredFirst() EvictionPolicy {
+	return &firedFirstPolicy{
+		lruPolicy: *LRU().(*lruPolicy),
+	}
+}
+
+func (p *lruPolicy) Add(key interface{}, li Listener) {
+	if e, found := p.elems[key]; found {
+		e.Value.(*lruEntry).li = li
+		p.order.MoveToBack(e)
+		return
+	}
+	p.elems[key] = p.order.PushBack(&lruEntry{key: key, li: li})
+}
+
+func (p *lruPolicy) Access(key interface{}) {
+	if e, found := p.elems[key]; found {
+		p.order.MoveToBack(e)
+	}
+}
+
+func (p *lruPolicy) Remove(key interface{}) {
+	if e, found := p.elems[key]; found {
+		p.order.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lruPolicy) Victims(evict func(key interface{}) bool) {
+	for e := p.order.Front(); e != nil; e = e.Next() {
+		if evict(e.Value.(*lruEntry).key) {
+			return
+		}
+	}
+}
+
+func (p *firedFirstPolicy) Victims(evict func(key interface{}) bool) {
+	for e := p.order.Front(); e != nil; e = e.Next() {
+		if in, ok := e.Value.(*lruEntry).li.(Inspector); ok && in.State() != StatePending {
+			if evict(e.Value.(*lruEntry).key) {
+				return
+			}
+		}
+	}
+	p.lruPolicy.Victims(evict)
+}
+
+func (p *lfuPolicy) Add(key interface{}, _ Listener) {
+	if _, found := p.elems[key]; found {
+		p.Access(key)
+		return
+	}
+
+	front := p.freqs.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = p.freqs.PushFront(&lfuBucket{freq: 1, items: list.New()})
+	}
+	p.elems[key] = front.Value.(*lfuBucket).items.PushBack(&lfuEntry{key: key, bucket: front})
+}
+
+func (p *lfuPolicy) Access(key interface{}) {
+	e, found := p.elems[key]
+	if !found {
+		return
+	}
+
+	entry := e.Value.(*lfuEntry)
+	cur := entry.bucket
+	freq := cur.Value.(*lfuBucket).freq + 1
+	next := cur.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != freq {
+		next = p.freqs.InsertAfter(&lfuBucket{freq: freq, items: list.New()}, cur)
+	}
+
+	p.unlink(e)
+	entry.bucket = next
+	p.elems[key] = next.Value.(*lfuBucket).items.PushBack(entry)
+}
+
+func (p *lfuPolicy) Remove(key interface{}) {
+	if e, found := p.elems[key]; found {
+		p.unlink(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lfuPolicy) unlink(e *list.Element) {
+	bucket := e.Value.(*lfuEntry).bucket
+	items := bucket.Value.(*lfuBucket).items
+	items.Remove(e)
+	if items.Len() == 0 {
+		p.freqs.Remove(bucket)
+	}
+}
+
+func (p *lfuPolicy) Victims(evict func(key interface{}) bool) {
+	for b := p.freqs.Front(); b != nil; b = b.Next() {
+		for e := b.Value.(*lfuBucket).items.Front(); e != nil; e = e.Next() {
+			if evict(e.Value.(*lfuEntry).key) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package expvarmetrics publishes listener registry metrics through expvar.
+package expvarmetrics
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/jenchik/listener"
+)
+
+type (
+	Metrics struct {
+		created    expvar.Int
+		hits       expvar.Int
+		misses     expvar.Int
+		deletes    expvar.Int
+		broadcasts expvar.Int
+		waiters    expvar.Int
+		waits      expvar.Int
+		waitNanos  expvar.Int
+		stuck      expvar.Int
+		vars       *expvar.Map
+	}
+)
+
+var (
+	_ listener.Metrics = &Metrics{}
+)
+
+// New publishes the metrics as an expvar map under name. As with
+// expvar.Publish, it panics if name is already registered.
+func New(name string) *Metrics {
+	m := &Metrics{
+		vars: new(expvar.Map).Init(),
+	}
+	m.vars.Set("created", &m.created)
+	m.vars.Set("hits", &m.hits)
+	m.vars.Set("misses", &m.misses)
+	m.vars.Set("deletes", &m.deletes)
+	m.vars.Set("broadcasts", &m.broadcasts)
+	m.vars.Set("waiters", &m.waiters)
+	m.vars.Set("waits", &m.waits)
+	m.vars.Set("wait_ns", &m.waitNanos)
+	m.vars.Set("stuck", &m.stuck)
+	expvar.Publish(name, m.vars)
+
+	return m
+}
+
+func (m *Metrics) Map() *expvar.Map {
+	return m.vars
+}
+
+func (m *Metrics) Created() {
+	m.created.Add(1)
+}
+
+func (m *Metrics) Hit() {
+	m.hits.Add(1)
+}
+
+func (m *Metrics) Miss() {
+	m.misses.Add(1)
+}
+
+func (m *Metrics) Deleted() {
+	m.deletes.Add(1)
+}
+
+func (m *Metrics) Broadcast() {
+	m.broadcasts.Add(1)
+}
+
+func (m *Metrics) WaitStarted() {
+	m.waiters.Add(1)
+}
+
+func (m *Metrics) WaitDone(d time.Duration) {
+	m.waiters.Add(-1)
+	m.waits.Add(1)
+	m.waitNanos.Add(int64(d))
+}
+
+// Stuck counts stuck waiters; it can be passed to listener.NewWatchdog.
+func (m *Metrics) Stuck(listener.StuckWaiter) {
+	m.stuck.Add(1)
+}
@@ -0,0 +1,50 @@
+package expvarmetrics_test
+
+import (
+	"expvar"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jenchik/listener"
+	"github.com/jenchik/listener/expvarmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+var runs int32
+
+func TestMetrics(t *testing.T) {
+	// expvar names cannot be reused, and -count runs the test again
+	name := fmt.Sprintf("listener_test_%d", atomic.AddInt32(&runs, 1))
+	m := expvarmetrics.New(name)
+	ls := listener.NewStringListenersWith(listener.WithMetrics(m))
+
+	li, _ := ls.GetOrCreate("key1")
+	ls.GetOrCreate("key1")
+	ls.GetOrCreate("key2")
+	li.Broadcast(1)
+	assert.Equal(t, 1, li.Wait())
+	ls.Delete("key1")
+	ls.Delete("key3")
+
+	v := expvar.Get(name).(*expvar.Map)
+	assert.Equal(t, "2", v.Get("created").String())
+	assert.Equal(t, "1", v.Get("hits").String())
+	assert.Equal(t, "2", v.Get("misses").String())
+	assert.Equal(t, "1", v.Get("deletes").String())
+	assert.Equal(t, "1", v.Get("broadcasts").String())
+	assert.Equal(t, "0", v.Get("waiters").String())
+	assert.Equal(t, "1", v.Get("waits").String())
+
+	w := listener.NewWatchdog(0, m.Stuck)
+	ls = listener.NewStringListenersWith(listener.WithMetrics(m), listener.WithWatchdog(w))
+	li, _ = ls.GetOrCreate("key1")
+	go li.Wait()
+	for v.Get("waiters").String() != "1" {
+		runtime.Gosched()
+	}
+	w.Check()
+	assert.Equal(t, "1", v.Get("stuck").String())
+	li.Broadcast(nil)
+}
@@ -0,0 +1,88 @@
+package listener
+
+import (
+	"errors"
+	"time"
+)
+
+type (
+	State uint32
+
+	Inspector interface {
+		State() State
+		Waiters() int
+		CreatedAt() time.Time
+		// FiredAt is when the listener last went from pending to fired;
+		// rebroadcasting a value it already holds does not move it.
+		FiredAt() time.Time
+		BroadcastCount() uint64
+	}
+
+	Closer interface {
+		Close(err error)
+	}
+
+	Stats struct {
+		Keys       int
+		Pending    int
+		Fired      int
+		Closed     int
+		Stuck      int // pending keys with at least one waiter
+		Waiters    int
+		Broadcasts uint64
+	}
+)
+
+const (
+	StatePending State = iota
+	StateFired
+	StateClosed
+)
+
+var (
+	ErrClosed = errors.New("listener: closed")
+)
+
+func (s State) String() string {
+	switch s {
+	case StatePending:
+		return "pending"
+	case StateFired:
+		return "fired"
+	case StateClosed:
+		return "closed"
+	}
+
+	return "unknown"
+}
+
+func (s *Stats) add(li Listener) {
+	s.Keys++
+	in, ok := unwrap(li).(Inspector)
+	if !ok {
+		return
+	}
+
+	w := in.Waiters()
+	switch in.State() {
+	case StatePending:
+		s.Pending++
+		if w > 0 {
+			s.Stuck++
+		}
+	case StateFired:
+		s.Fired++
+	case StateClosed:
+		s.Closed++
+	}
+	s.Waiters += w
+	s.Broadcasts += in.BroadcastCount()
+}
+
+func unixTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ns)
+}
@@ -0,0 +1,76 @@
+package listener
+
+type (
+	Interceptor interface {
+		OnCreate(key interface{}, li Listener)
+		// OnBroadcast may replace the value or veto the broadcast by
+		// returning an error. For Update and CompareAndBroadcast it
+		// runs with the listener locked: it must not use that listener
+		// in any way, not even its Inspector, or it deadlocks.
+		OnBroadcast(key interface{}, value interface{}) (interface{}, error)
+		OnWake(key interface{}, value interface{})
+		OnDelete(key interface{}, li Listener)
+	}
+
+	// InterceptorFuncs adapts a set of optional functions to Interceptor.
+	InterceptorFuncs struct {
+		Create    func(key interface{}, li Listener)
+		Broadcast func(key interface{}, value interface{}) (interface{}, error)
+		Wake      func(key interface{}, value interface{})
+		Delete    func(key interface{}, li Listener)
+	}
+
+	TryBroadcaster interface {
+		TryBroadcast(value interface{}) error
+	}
+)
+
+var (
+	_ Interceptor = InterceptorFuncs{}
+)
+
+func WithInterceptors(ics ...Interceptor) Option {
+	return func(c *config) {
+		for _, ic := range ics {
+			if ic != nil {
+				c.interceptors = append(c.interceptors, ic)
+			}
+		}
+	}
+}
+
+// TryBroadcast broadcasts value and reports whether an interceptor vetoed it.
+func TryBroadcast(li Listener, value interface{}) error {
+	if tb, ok := li.(TryBroadcaster); ok {
+		return tb.TryBroadcast(value)
+	}
+	li.Broadcast(value)
+
+	return nil
+}
+
+func (f InterceptorFuncs) OnCreate(key interface{}, li Listener) {
+	if f.Create != nil {
+		f.Create(key, li)
+	}
+}
+
+func (f InterceptorFuncs) OnBroadcast(key interface{}, value interface{}) (interface{}, error) {
+	if f.Broadcast != nil {
+		return f.Broadcast(key, value)
+	}
+
+	return value, nil
+}
+
+func (f InterceptorFuncs) OnWake(key interface{}, value interface{}) {
+	if f.Wake != nil {
+		f.Wake(key, value)
+	}
+}
+
+func (f InterceptorFuncs) OnDelete(key interface{}, li Listener) {
+	if f.Delete != nil {
+		f.Delete(key, li)
+	}
+}
@@ -1,81 +1,194 @@
 package listener
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 type (
 	IntListeners struct {
-		creater func() Listener
-		lmap    map[int]Listener
-		mu      sync.RWMutex
+		config
+		store intStore
+	}
+
+	intMapStore struct {
+		lmap map[int]Listener
+		mu   sync.RWMutex
 	}
 )
 
 func NewIntListeners(creater ...func() Listener) *IntListeners {
-	var c func() Listener = NewListener
-	if len(creater) != 0 && creater[0] != nil {
-		c = creater[0]
-	}
+	return NewIntListenersWith(firstCreater(creater))
+}
 
-	return &IntListeners{
-		creater: c,
-		lmap:    make(map[int]Listener, 8),
+func NewIntListenersWith(opts ...Option) *IntListeners {
+	l := &IntListeners{
+		config: newConfig(opts),
+	}
+	if l.backend.intStore != nil {
+		l.store = l.backend.intStore(&l.config)
+	} else {
+		l.store = &intMapStore{
+			lmap: make(map[int]Listener, 8),
+		}
+	}
+	if l.backend.intBound != nil {
+		l.store = l.backend.intBound(l.store, &l.config)
 	}
+
+	return l
 }
 
-func (l *IntListeners) GetOrCreate(key int) (li Listener, found bool) {
-	l.mu.RLock()
-	li, found = l.lmap[key]
-	l.mu.RUnlock()
+func (l *IntListeners) GetOrCreate(key int) (Listener, bool) {
+	li, found := l.store.load(key)
 	if !found {
-		l.mu.Lock()
-		li, found = l.lmap[key]
+		li, found = l.store.loadOrCreate(key, &l.config)
 		if !found {
-			li = l.creater()
-			l.lmap[key] = li
+			l.created(key, li)
+			return li, false
 		}
-		l.mu.Unlock()
+	}
+	l.metrics.Hit()
+	if l.keyed {
+		l.accessed(key, li)
 	}
 
-	return
+	return li, true
 }
 
-func (l *IntListeners) Get(key int) (li Listener, found bool) {
-	l.mu.RLock()
-	li, found = l.lmap[key]
-	l.mu.RUnlock()
+func (l *IntListeners) Get(key int) (Listener, bool) {
+	return l.store.load(key)
+}
 
-	return
+func (l *IntListeners) GetReceiver(key int) (Receiver, bool) {
+	if li, found := l.Get(key); found {
+		return ReadOnly(li), true
+	}
+
+	return nil, false
 }
 
 func (l *IntListeners) Len() int {
-	return len(l.lmap)
+	return l.store.len()
 }
 
 func (l *IntListeners) Delete(key int) {
-	l.mu.Lock()
-	delete(l.lmap, key)
-	l.mu.Unlock()
+	if li, found := l.store.loadAndDelete(key); found {
+		l.metrics.Deleted()
+		if l.keyed {
+			l.deleted(key, li)
+		}
+		if l.pooled {
+			l.release(li)
+		}
+	}
 }
 
-func (l *IntListeners) Put(key int, li Listener) (old Listener) {
-	l.mu.Lock()
-	old = l.lmap[key]
-	if li != nil {
-		l.lmap[key] = li
+func (l *IntListeners) Put(key int, li Listener) Listener {
+	if li == nil {
+		old, _ := l.store.load(key)
+		return old
 	}
-	l.mu.Unlock()
 
-	return
+	return l.store.swap(key, li)
 }
 
 func (l *IntListeners) Range(f func(key int, li Listener) bool) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	for key, li := range l.lmap {
+	l.store.rangeAll(f)
+}
+
+// BroadcastAfter broadcasts value after d to the listener of key, created
+// now if missing. Stop the returned timer to cancel.
+func (l *IntListeners) BroadcastAfter(key int, d time.Duration, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAfter(li, d, value)
+}
+
+// BroadcastAt is BroadcastAfter with an absolute time.
+func (l *IntListeners) BroadcastAt(key int, t time.Time, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAt(li, t, value)
+}
+
+// WaitUntilKey is WaitUntil on the listener of key, created now if
+// missing.
+func (l *IntListeners) WaitUntilKey(ctx context.Context, key int, pred func(value interface{}) bool) (interface{}, error) {
+	li, _ := l.GetOrCreate(key)
+	return WaitUntil(ctx, li, pred)
+}
+
+func (l *IntListeners) Stats() (s Stats) {
+	l.Range(func(_ int, li Listener) bool {
+		s.add(li)
+		return true
+	})
+
+	return
+}
+
+func (s *intMapStore) load(key int) (li Listener, found bool) {
+	s.mu.RLock()
+	li, found = s.lmap[key]
+	s.mu.RUnlock()
+
+	return
+}
+
+func (s *intMapStore) loadOrCreate(key int, c *config) (li Listener, found bool) {
+	s.mu.Lock()
+	li, found = s.lmap[key]
+	if !found {
+		li = c.newListener(key)
+		s.lmap[key] = li
+	}
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *intMapStore) loadOrStore(key int, li Listener) (old Listener, found bool) {
+	s.mu.Lock()
+	if old, found = s.lmap[key]; !found {
+		s.lmap[key] = li
+		old = li
+	}
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *intMapStore) swap(key int, li Listener) (old Listener) {
+	s.mu.Lock()
+	old = s.lmap[key]
+	s.lmap[key] = li
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *intMapStore) loadAndDelete(key int) (li Listener, found bool) {
+	s.mu.Lock()
+	li, found = s.lmap[key]
+	delete(s.lmap, key)
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *intMapStore) rangeAll(f func(key int, li Listener) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, li := range s.lmap {
 		if !f(key, li) {
 			break
 		}
 	}
 }
+
+func (s *intMapStore) len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.lmap)
+}
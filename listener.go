@@ -1,63 +1,285 @@
 package listener
 
 import (
+	"runtime"
 	"sync"
 	"sync/atomic"
-	"unsafe"
+	"time"
 )
 
 type (
+	ListenerOption func(*listener)
+
 	listener struct {
-		cond    sync.Cond
+		count   uint64
+		created int64
+		fired   int64
+		expires int64 // unix ns after which value is stale, 0 if never
+		ttl     time.Duration
+		clock   Clock
+		mu      sync.RWMutex
+		value   interface{}
+		wake    *wakeup       // made by the first waiter that parks
+		changed chan struct{} // made by WaitUntil, closed on every change
 		trigger uint32
-		p       unsafe.Pointer
+		closed  uint32
+		waiters int32
+		spins   int32 // current spin budget, adapted on every wait
+		maxSpin int32
+	}
+
+	// wakeup hands parked waiters the value that released them, which a
+	// later broadcast may already have replaced by the time they run.
+	wakeup struct {
+		done  chan struct{}
+		value interface{}
 	}
 )
 
+const (
+	activeSpins = 4
+)
+
 var (
-	_ Listener = &listener{}
+	_ Listener    = &listener{}
+	_ Broadcaster = &listener{}
+	_ Receiver    = &listener{}
+	_ Inspector   = &listener{}
+	_ Closer      = &listener{}
 )
 
 func NewListener() Listener {
 	return newListener()
 }
 
+func NewListenerWith(opts ...ListenerOption) Listener {
+	l := newListener()
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.clock != nil {
+		l.created = l.now()
+	}
+
+	return l
+}
+
+// WithSpin makes waiters poll for up to spins rounds, busy at first and
+// then yielding with runtime.Gosched, before they park. The budget adapts:
+// it is halved after a round that ended up parking and doubled, up to
+// spins, after one that did not.
+func WithSpin(spins int) ListenerOption {
+	return func(l *listener) {
+		if spins < 0 {
+			spins = 0
+		}
+		l.spins = int32(spins)
+		l.maxSpin = int32(spins)
+	}
+}
+
 func newListener() *listener {
 	return &listener{
-		cond: sync.Cond{L: locker{}},
+		created: time.Now().UnixNano(),
 	}
 }
 
 func (l *listener) Broadcast(value interface{}) {
-	atomic.StorePointer(&l.p, unsafe.Pointer(&value))
+	atomic.AddUint64(&l.count, 1)
+
+	l.mu.Lock()
+	if l.closed == 0 {
+		l.fire(value, l.ttl)
+	}
+	l.mu.Unlock()
+}
 
-	if atomic.CompareAndSwapUint32(&l.trigger, 0, 1) {
-		l.cond.Broadcast()
+// fire must be called with l.mu held. A ttl <= 0 never expires. The
+// clock is only read when the listener becomes fired or values expire,
+// which keeps plain rebroadcasts cheap.
+func (l *listener) fire(value interface{}, ttl time.Duration) {
+	var now int64
+	if l.trigger == 0 || ttl > 0 || l.expires != 0 {
+		now = l.now()
+	}
+	l.value = value
+	if l.trigger == 0 || (l.expires != 0 && now >= l.expires) {
+		atomic.StoreInt64(&l.fired, now)
+	}
+	if ttl > 0 {
+		atomic.StoreInt64(&l.expires, now+int64(ttl))
+	} else if l.expires != 0 {
+		atomic.StoreInt64(&l.expires, 0)
+	}
+
+	l.notify()
+	if l.trigger == 0 {
+		atomic.StoreUint32(&l.trigger, 1)
+		if l.wake != nil {
+			l.wake.value = value
+			close(l.wake.done)
+			l.wake = nil
+		}
 	}
 }
 
 func (l *listener) Receive() (interface{}, bool) {
-	if atomic.LoadUint32(&l.trigger) == 0 {
+	if !l.ready() {
 		return nil, false
 	}
 
-	p := atomic.LoadPointer(&l.p)
-	if p == nil {
-		return nil, true
+	return l.load()
+}
+
+func (l *listener) Wait() interface{} {
+	if l.ready() {
+		if value, ok := l.load(); ok {
+			return value
+		}
+	}
+
+	atomic.AddInt32(&l.waiters, 1)
+	defer atomic.AddInt32(&l.waiters, -1)
+	if l.maxSpin != 0 && l.spin() {
+		if value, ok := l.load(); ok {
+			return value
+		}
 	}
 
-	return *(*interface{})(p), true
+	return l.park()
 }
 
-func (l *listener) Wait() interface{} {
-	if atomic.LoadUint32(&l.trigger) == 0 {
-		l.cond.Wait()
+// ready reports whether the listener holds a value that has not expired.
+func (l *listener) ready() bool {
+	return atomic.LoadUint32(&l.trigger) != 0 && !l.expired()
+}
+
+func (l *listener) expired() bool {
+	expires := atomic.LoadInt64(&l.expires)
+	return expires != 0 && l.now() >= expires
+}
+
+func (l *listener) now() int64 {
+	if l.clock != nil {
+		return l.clock.Now().UnixNano()
+	}
+
+	return time.Now().UnixNano()
+}
+
+func (l *listener) spin() bool {
+	budget := atomic.LoadInt32(&l.spins)
+	for i := int32(0); i < budget; i++ {
+		if i < activeSpins {
+			for j := 0; j < 1<<uint(i); j++ {
+				if l.ready() {
+					break
+				}
+			}
+		} else {
+			runtime.Gosched()
+		}
+
+		if l.ready() {
+			if budget < l.maxSpin {
+				if budget *= 2; budget > l.maxSpin {
+					budget = l.maxSpin
+				}
+				atomic.StoreInt32(&l.spins, budget)
+			}
+			return true
+		}
+	}
+
+	if budget > 1 {
+		atomic.StoreInt32(&l.spins, budget/2)
+	}
+
+	return false
+}
+
+func (l *listener) park() interface{} {
+	l.mu.Lock()
+	if l.trigger != 0 {
+		if !l.expired() {
+			value := l.value
+			l.mu.Unlock()
+			return value
+		}
+		// wait for a fresh value
+		atomic.StoreUint32(&l.trigger, 0)
+		atomic.StoreInt64(&l.expires, 0)
+		l.value = nil
+	}
+	if l.wake == nil {
+		l.wake = &wakeup{done: make(chan struct{})}
+	}
+	w := l.wake
+	l.mu.Unlock()
+
+	<-w.done
+
+	return w.value
+}
+
+// load returns the value unless it has expired or been cleared since
+// the caller checked.
+func (l *listener) load() (value interface{}, ok bool) {
+	l.mu.RLock()
+	if l.trigger != 0 {
+		value, ok = l.value, !l.expired()
+	}
+	l.mu.RUnlock()
+
+	return
+}
+
+// Close resolves pending waiters with err (ErrClosed if nil) and drops
+// all further broadcasts. A value that has already fired is kept, and no
+// longer expires.
+func (l *listener) Close(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed != 0 {
+		return
 	}
+	atomic.StoreUint32(&l.closed, 1)
 
-	p := atomic.LoadPointer(&l.p)
-	if p == nil {
-		return nil
+	if l.trigger == 0 || l.expired() {
+		if err == nil {
+			err = ErrClosed
+		}
+		l.fire(err, 0)
+	} else {
+		atomic.StoreInt64(&l.expires, 0)
+		l.notify()
+	}
+}
+
+func (l *listener) State() State {
+	if atomic.LoadUint32(&l.closed) != 0 {
+		return StateClosed
+	}
+	if l.ready() {
+		return StateFired
 	}
 
-	return *(*interface{})(p)
+	return StatePending
+}
+
+func (l *listener) Waiters() int {
+	return int(atomic.LoadInt32(&l.waiters))
+}
+
+func (l *listener) CreatedAt() time.Time {
+	return unixTime(l.created)
+}
+
+func (l *listener) FiredAt() time.Time {
+	return unixTime(atomic.LoadInt64(&l.fired))
+}
+
+func (l *listener) BroadcastCount() uint64 {
+	return atomic.LoadUint64(&l.count)
 }
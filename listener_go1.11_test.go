@@ -0,0 +1,66 @@
+//go:build go1.11
+// +build go1.11
+
+package listener_test
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+	"testing"
+
+	. "github.com/jenchik/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenersProfiling(t *testing.T) {
+	ls := NewStringListenersWith(WithName("calls"), WithProfiling())
+	li, _ := ls.GetOrCreate("key1")
+
+	var tr bytes.Buffer
+	assert.NoError(t, trace.Start(&tr))
+
+	done := make(chan struct{})
+	go func() {
+		li.Wait()
+		close(done)
+	}()
+	waitWaiters(li, 1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+	assert.Contains(t, buf.String(), `"listener.key":"key1"`)
+	assert.Contains(t, buf.String(), `"listener.registry":"calls"`)
+
+	li.Broadcast(1)
+	<-done
+	trace.Stop()
+	assert.Contains(t, tr.String(), "listener.Broadcast")
+	assert.Contains(t, tr.String(), "listener.Wait")
+
+	// WaitUntil merges the labels of its context and puts them back
+	li, _ = ls.GetOrCreate("key2")
+	woke, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		pprof.Do(context.Background(), pprof.Labels("caller", "test"), func(ctx context.Context) {
+			WaitUntil(ctx, li, func(interface{}) bool { return true })
+			close(woke)
+			<-release
+		})
+	}()
+	waitWaiters(li, 1)
+
+	buf.Reset()
+	assert.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+	assert.Contains(t, buf.String(), `"caller":"test", "listener.key":"key2"`)
+
+	li.Broadcast(2)
+	<-woke
+
+	buf.Reset()
+	assert.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+	close(release)
+	assert.Contains(t, buf.String(), `"caller":"test"`)
+	assert.NotContains(t, buf.String(), `"listener.key":"key2"`)
+}
@@ -0,0 +1,133 @@
+//go:build go1.18
+// +build go1.18
+
+package listener_test
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/jenchik/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoundedListenersLRU(t *testing.T) {
+	m := new(countMetrics)
+	ls := NewStringListenersWith(WithMaxEntries(2), WithMetrics(m))
+
+	a, _ := ls.GetOrCreate("a")
+	b, _ := ls.GetOrCreate("b")
+	ls.Get("a")
+	ls.GetOrCreate("c")
+
+	assert.Equal(t, 2, ls.Len())
+	_, found := ls.Get("b")
+	assert.False(t, found)
+	assert.Equal(t, ErrEvicted, b.Wait())
+	assert.Equal(t, StatePending, a.(Inspector).State())
+	assert.Equal(t, 1, m.deletes)
+
+	ls.Delete("a")
+	ls.GetOrCreate("d")
+	assert.Equal(t, 2, ls.Len())
+	_, found = ls.Get("c")
+	assert.True(t, found)
+}
+
+func TestBoundedListenersLFU(t *testing.T) {
+	var evicted []interface{}
+	ls := NewIntListenersWith(WithMaxEntries(2, EvictionOptions{
+		Policy: LFU,
+		OnEvict: func(key interface{}, li Listener) {
+			evicted = append(evicted, key)
+		},
+	}))
+
+	a, _ := ls.GetOrCreate(1)
+	for i := 0; i < 3; i++ {
+		ls.GetOrCreate(1)
+	}
+	ls.GetOrCreate(2)
+	ls.GetOrCreate(2)
+	ls.GetOrCreate(3)
+	ls.GetOrCreate(4)
+
+	assert.Equal(t, []interface{}{2, 3}, evicted)
+	assert.Equal(t, 2, ls.Len())
+	assert.Equal(t, StatePending, a.(Inspector).State())
+}
+
+func TestBoundedListenersFiredFirst(t *testing.T) {
+	ls := NewListenersWith(WithMaxEntries(2, EvictionOptions{Policy: FiredFirst}))
+
+	ls.GetOrCreate("a")
+	b, _ := ls.GetOrCreate("b")
+	b.Broadcast("foo")
+	ls.Put("c", NewListenerOnce())
+
+	_, found := ls.Get("a")
+	assert.True(t, found)
+	_, found = ls.Get("b")
+	assert.False(t, found)
+	assert.Equal(t, "foo", b.Wait())
+	assert.Equal(t, StateClosed, b.(Inspector).State())
+}
+
+func TestBoundedListenersWaiters(t *testing.T) {
+	ls := NewStringListenersWith(WithMaxEntries(1))
+
+	a, _ := ls.GetOrCreate("a")
+	done := make(chan interface{})
+	go func() {
+		done <- a.Wait()
+	}()
+	waitWaiters(a, 1)
+
+	// never evicted while waited on, so the registry overflows
+	ls.GetOrCreate("b")
+	assert.Equal(t, 2, ls.Len())
+	_, found := ls.Get("a")
+	assert.True(t, found)
+	a.Broadcast("foo")
+	assert.Equal(t, "foo", <-done)
+
+	// and shrinks back on the next insert
+	ls.GetOrCreate("c")
+	assert.Equal(t, 1, ls.Len())
+	_, found = ls.Get("c")
+	assert.True(t, found)
+
+	ls = NewStringListenersWith(WithMaxEntries(1, EvictionOptions{EvictWaiting: true}))
+	a, _ = ls.GetOrCreate("a")
+	go func() {
+		done <- a.Wait()
+	}()
+	waitWaiters(a, 1)
+	b, _ := ls.GetOrCreate("b")
+	assert.Equal(t, ErrEvicted, <-done)
+	assert.Equal(t, StatePending, b.(Inspector).State())
+	assert.Equal(t, 1, ls.Len())
+}
+
+func TestBoundedListenersConcurrent(t *testing.T) {
+	m := new(countMetrics)
+	ls := NewIntListenersWith(WithMaxEntries(16, EvictionOptions{Policy: LFU}), WithMetrics(m))
+
+	const workers, keys = 8, 500
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := 0; k < keys; k++ {
+				li, _ := ls.GetOrCreate(w*keys + k)
+				li.Broadcast(k)
+				ls.Get(k)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 16, ls.Len())
+	assert.Equal(t, workers*keys-16, m.deletes)
+}
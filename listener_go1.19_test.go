@@ -0,0 +1,219 @@
+//go:build go1.19
+// +build go1.19
+
+package listener_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/jenchik/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDenseIntListeners(t *testing.T) {
+	ls := NewDenseIntListeners(4)
+	assert.Equal(t, 4, ls.Cap())
+	assert.Equal(t, 0, ls.Len())
+
+	li, found := ls.Get(1)
+	assert.Nil(t, li)
+	assert.False(t, found)
+	li, found = ls.Get(100)
+	assert.Nil(t, li)
+	assert.False(t, found)
+
+	li1, found := ls.GetOrCreate(1)
+	assert.NotNil(t, li1)
+	assert.False(t, found)
+	liX, found := ls.GetOrCreate(1)
+	assert.True(t, found)
+	assert.True(t, li1 == liX)
+
+	li9, found := ls.GetOrCreate(9)
+	assert.False(t, found)
+	assert.Equal(t, 10, ls.Cap())
+	assert.Equal(t, 2, ls.Len())
+	liX, _ = ls.Get(1)
+	assert.True(t, li1 == liX)
+
+	li2 := NewListenerOnce()
+	assert.Nil(t, ls.Put(2, li2))
+	assert.True(t, ls.Put(2, NewListener()) == li2)
+	assert.Equal(t, 3, ls.Len())
+
+	var keys []int
+	ls.Range(func(key int, li Listener) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 9}, keys)
+
+	ls.Delete(1)
+	ls.Delete(1)
+	ls.Delete(-1)
+	ls.Delete(1000)
+	assert.Equal(t, 2, ls.Len())
+	_, found = ls.Get(1)
+	assert.False(t, found)
+
+	li9.Broadcast("foo")
+	r, _ := ls.GetReceiver(9)
+	assert.Equal(t, "foo", r.Wait())
+	assert.Equal(t, Stats{Keys: 2, Pending: 1, Fired: 1, Broadcasts: 1}, ls.Stats())
+
+	ls.Grow(64)
+	assert.Equal(t, 64, ls.Cap())
+	liX, _ = ls.Get(9)
+	assert.True(t, li9 == liX)
+
+	for _, key := range []int{-1, MaxDenseGrow, 1 << 40} {
+		li, found, err := ls.TryGetOrCreate(key)
+		assert.Nil(t, li)
+		assert.False(t, found)
+		assert.Equal(t, ErrKeyRange, err)
+		assert.Equal(t, ErrKeyRange, recovered(func() {
+			ls.GetOrCreate(key)
+		}))
+		assert.Equal(t, ErrKeyRange, recovered(func() {
+			ls.BroadcastAfter(key, time.Hour, 1)
+		}))
+		_, err = ls.WaitUntilKey(context.Background(), key, func(interface{}) bool { return true })
+		assert.Equal(t, ErrKeyRange, err)
+		_, found = ls.Get(key)
+		assert.False(t, found)
+		assert.Nil(t, ls.Put(key, NewListener()))
+	}
+	assert.Equal(t, 64, ls.Cap())
+	assert.Equal(t, 2, ls.Len())
+
+	// a bounded table only grows through Grow
+	ls = NewDenseIntListenersWith(4, WithMaxEntries(2))
+	for key := 0; key < 100; key++ {
+		ls.TryGetOrCreate(key)
+	}
+	assert.Equal(t, 4, ls.Cap())
+	assert.Equal(t, 4, ls.Len())
+	_, _, err := ls.TryGetOrCreate(4)
+	assert.Equal(t, ErrKeyRange, err)
+	ls.Grow(8)
+	_, found = ls.GetOrCreate(4)
+	assert.False(t, found)
+	assert.Equal(t, 5, ls.Len())
+}
+
+func recovered(f func()) (v interface{}) {
+	defer func() {
+		v = recover()
+	}()
+	f()
+
+	return
+}
+
+func TestDenseIntListenersConcurrent(t *testing.T) {
+	m := new(countMetrics)
+	ls := NewDenseIntListenersWith(1, WithMetrics(m))
+
+	const workers, keys = 8, 200
+	var wg sync.WaitGroup
+	got := make([][]Listener, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := 0; k < keys; k++ {
+				li, _ := ls.GetOrCreate(k)
+				got[w] = append(got[w], li)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, keys, ls.Len())
+	assert.Equal(t, keys, m.created)
+	for w := 1; w < workers; w++ {
+		for k := 0; k < keys; k++ {
+			assert.True(t, got[0][k] == got[w][k])
+		}
+	}
+}
+
+func TestCopyOnWriteListeners(t *testing.T) {
+	m := new(countMetrics)
+	ls := NewStringListenersWith(WithCopyOnWrite(), WithMetrics(m))
+
+	li1, found := ls.GetOrCreate("a")
+	assert.False(t, found)
+	li2, found := ls.GetOrCreate("a")
+	assert.True(t, found)
+	assert.True(t, li1 == li2)
+	ls.GetOrCreate("b")
+
+	// Range works on a snapshot, so writes from the callback are safe
+	// and not observed by the running iteration.
+	n := 0
+	ls.Range(func(key string, li Listener) bool {
+		ls.Delete(key)
+		ls.GetOrCreate(key + key)
+		n++
+		return true
+	})
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, ls.Len())
+	_, found = ls.Get("a")
+	assert.False(t, found)
+	_, found = ls.Get("aa")
+	assert.True(t, found)
+
+	li3 := NewListener()
+	assert.Nil(t, ls.Put("c", li3))
+	assert.True(t, ls.Put("c", nil) == li3)
+	assert.True(t, ls.Put("c", NewListener()) == li3)
+	li3, _ = ls.Get("c")
+	li3.Broadcast(1)
+	assert.Equal(t, Stats{Keys: 3, Pending: 2, Fired: 1, Broadcasts: 1}, ls.Stats())
+	assert.Equal(t, 4, m.created)
+	assert.Equal(t, 1, m.hits)
+	assert.Equal(t, 2, m.deletes)
+
+	is := NewIntListenersWith(WithCopyOnWrite())
+	is.GetOrCreate(1)
+	r, found := is.GetReceiver(1)
+	assert.True(t, found)
+	assert.NotNil(t, r)
+
+	as := NewListenersWith(WithCopyOnWrite(), WithCreater(NewListenerOnce))
+	as.GetOrCreate(1)
+	as.GetOrCreate("1")
+	assert.Equal(t, 2, as.Len())
+}
+
+func TestCopyOnWriteListenersConcurrent(t *testing.T) {
+	ls := NewIntListenersWith(WithCopyOnWrite())
+
+	const workers, keys = 8, 100
+	var wg sync.WaitGroup
+	got := make([][]Listener, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := 0; k < keys; k++ {
+				li, _ := ls.GetOrCreate(k)
+				got[w] = append(got[w], li)
+				ls.Len()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, keys, ls.Len())
+	for w := 1; w < workers; w++ {
+		for k := 0; k < keys; k++ {
+			assert.True(t, got[0][k] == got[w][k])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+//go:build go1.21
+// +build go1.21
+
+package listener_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/jenchik/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+type logRecords struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *logRecords) Enabled(_ context.Context, level slog.Level) bool { return level >= h.level }
+func (h *logRecords) WithAttrs([]slog.Attr) slog.Handler               { return h }
+func (h *logRecords) WithGroup(string) slog.Handler                    { return h }
+
+func (h *logRecords) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	h.records = append(h.records, r)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *logRecords) messages() (msgs []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		var key, valueType string
+		r.Attrs(func(a slog.Attr) bool {
+			switch a.Key {
+			case "key":
+				key = " key=" + a.Value.String()
+			case "value_type":
+				valueType = " value_type=" + a.Value.String()
+			}
+			return true
+		})
+		msgs = append(msgs, r.Message+key+valueType)
+	}
+	return
+}
+
+func TestListenersLogger(t *testing.T) {
+	h := &logRecords{level: slog.LevelDebug}
+	ls := NewStringListenersWith(WithLogger(slog.New(h)))
+
+	li, _ := ls.GetOrCreate("key1")
+	ls.GetOrCreate("key1")
+	go func() {
+		waitWaiters(li, 1)
+		li.Broadcast(42)
+	}()
+	assert.Equal(t, 42, li.Wait())
+	ls.Delete("key1")
+
+	assert.Equal(t, []string{
+		"listener.create key=key1",
+		"listener.hit key=key1",
+		"listener.wait key=key1",
+		"listener.broadcast key=key1 value_type=int",
+		"listener.wake key=key1 value_type=int",
+		"listener.delete key=key1",
+	}, h.messages())
+}
+
+func TestListenersLoggerSampling(t *testing.T) {
+	h := &logRecords{level: slog.LevelInfo}
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	ls := NewIntListenersWith(WithLogger(slog.New(h), LogOptions{
+		Level:  level,
+		Sample: 10,
+		Burst:  2,
+	}))
+
+	ls.GetOrCreate(1)
+	assert.Len(t, h.messages(), 0)
+
+	level.Set(slog.LevelInfo)
+	for i := 0; i < 32; i++ {
+		ls.GetOrCreate(1)
+		ls.GetOrCreate(2)
+	}
+	// disabled events are not counted, so per key: 1, 2, 12, 22 and 32
+	assert.Len(t, h.messages(), 10)
+}
+
+func TestLoggedListenerSlowWait(t *testing.T) {
+	h := &logRecords{level: slog.LevelInfo}
+	li := NewLoggedListener(NewListenerOnce(), "call", slog.New(h), LogOptions{
+		SlowWait: time.Millisecond,
+	})
+
+	time.AfterFunc(5*time.Millisecond, func() {
+		li.Broadcast("ok")
+	})
+	assert.Equal(t, "ok", li.Wait())
+
+	assert.Equal(t, []string{"listener.wake key=call value_type=string"}, h.messages())
+	assert.Equal(t, slog.LevelWarn, h.records[0].Level)
+}
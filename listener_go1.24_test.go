@@ -0,0 +1,223 @@
+//go:build go1.24
+// +build go1.24
+
+package listener_test
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/jenchik/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedListeners(t *testing.T) {
+	m := new(countMetrics)
+	var deleted []interface{}
+	opts := []Option{
+		WithShards(4),
+		WithMetrics(m),
+		WithCreater(NewListenerOnce),
+		WithInterceptors(InterceptorFuncs{
+			Delete: func(key interface{}, li Listener) {
+				deleted = append(deleted, key)
+			},
+		}),
+	}
+
+	ls := NewListenersWith(opts...)
+	li1, found := ls.GetOrCreate("key1")
+	assert.False(t, found)
+	li2, found := ls.GetOrCreate("key1")
+	assert.True(t, found)
+	assert.True(t, li1 == li2)
+	ls.GetOrCreate(2)
+	assert.Equal(t, 2, ls.Len())
+
+	li3 := NewListener()
+	assert.Nil(t, ls.Put(3, li3))
+	assert.True(t, ls.Put(3, nil) == li3)
+	assert.True(t, ls.Put(3, NewListener()) == li3)
+	assert.Equal(t, 3, ls.Len())
+
+	li1.Broadcast("foo")
+	assert.Equal(t, Stats{Keys: 3, Pending: 2, Fired: 1, Broadcasts: 1}, ls.Stats())
+
+	ls.Delete("key1")
+	ls.Delete("key1")
+	assert.Equal(t, 2, ls.Len())
+	_, found = ls.Get("key1")
+	assert.False(t, found)
+	assert.Equal(t, []interface{}{"key1"}, deleted)
+	assert.Equal(t, 2, m.created)
+	assert.Equal(t, 1, m.hits)
+	assert.Equal(t, 1, m.deletes)
+
+	n := 0
+	ls.Range(func(key interface{}, li Listener) bool {
+		n++
+		return false
+	})
+	assert.Equal(t, 1, n)
+
+	ss := NewStringListenersWith(WithShards(2))
+	ss.GetOrCreate("a")
+	ss.GetOrCreate("b")
+	r, found := ss.GetReceiver("a")
+	assert.True(t, found)
+	assert.NotNil(t, r)
+	ss.Delete("a")
+	assert.Equal(t, 1, ss.Len())
+
+	is := NewIntListenersWith(WithShards(2))
+	for i := 0; i < 100; i++ {
+		is.GetOrCreate(i)
+	}
+	assert.Equal(t, 100, is.Len())
+	keys := make(map[int]bool)
+	is.Range(func(key int, li Listener) bool {
+		keys[key] = true
+		return true
+	})
+	assert.Len(t, keys, 100)
+}
+
+func TestShardedListenersChurn(t *testing.T) {
+	m := new(countMetrics)
+	ls := NewStringListenersWith(WithShards(0), WithMetrics(m))
+
+	const workers, cycles = 8, 500
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < cycles; i++ {
+				key := fmt.Sprint(i % 50)
+				li, _ := ls.GetOrCreate(key)
+				li.Broadcast(w)
+				ls.Delete(key)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, ls.Len())
+	assert.Equal(t, m.created, m.deletes)
+	assert.Equal(t, workers*cycles, m.created+m.hits)
+}
+
+// collect runs the garbage collector until cond holds; cleanups run on
+// their own goroutine after the cycle that found the object unreachable.
+func collect(t *testing.T, cond func() bool) {
+	for i := 0; i < 100 && !cond(); i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, cond())
+}
+
+func TestWeakListeners(t *testing.T) {
+	m := new(countMetrics)
+	var deleted []interface{}
+	var mu sync.Mutex
+	ls := NewStringListenersWith(WithWeakRefs(), WithMetrics(m), WithInterceptors(InterceptorFuncs{
+		Delete: func(key interface{}, li Listener) {
+			mu.Lock()
+			deleted = append(deleted, key)
+			mu.Unlock()
+		},
+	}))
+
+	func() {
+		li, found := ls.GetOrCreate("gone")
+		assert.False(t, found)
+		li.Broadcast("foo")
+	}()
+	kept, _ := ls.GetOrCreate("kept")
+	waited, _ := ls.GetOrCreate("waited")
+	done := make(chan interface{})
+	go func(r Receiver) {
+		done <- r.Wait()
+	}(ReadOnly(waited))
+	waitWaiters(waited, 1)
+	waited = nil
+
+	collect(t, func() bool {
+		return ls.Len() == 2
+	})
+	_, found := ls.Get("gone")
+	assert.False(t, found)
+	li, found := ls.GetOrCreate("kept")
+	assert.True(t, found)
+	assert.True(t, li == kept)
+
+	// a goroutine parked in Wait keeps its key alive
+	waited, found = ls.Get("waited")
+	assert.True(t, found)
+	waited.Broadcast("bar")
+	assert.Equal(t, "bar", <-done)
+
+	collect(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(deleted) == 1
+	})
+	assert.Equal(t, []interface{}{"gone"}, deleted)
+	assert.Equal(t, 1, m.deletes)
+
+	n := 0
+	ls.Range(func(key string, li Listener) bool {
+		n++
+		return true
+	})
+	assert.Equal(t, 2, n)
+	assert.Equal(t, Stats{Keys: 2, Pending: 1, Fired: 1, Broadcasts: 1}, ls.Stats())
+	runtime.KeepAlive(kept)
+	runtime.KeepAlive(waited)
+}
+
+func TestWeakListenersBounded(t *testing.T) {
+	ls := NewIntListenersWith(WithWeakRefs(), WithMaxEntries(2))
+	var held []Listener
+	for i := 0; i < 4; i++ {
+		li, _ := ls.GetOrCreate(i)
+		held = append(held, li)
+	}
+	assert.Equal(t, 2, ls.Len())
+	assert.Equal(t, ErrEvicted, held[0].Wait())
+
+	held = nil
+	collect(t, func() bool {
+		return ls.Len() == 0
+	})
+}
+
+func TestWeakListenersPut(t *testing.T) {
+	ls := NewStringListenersWith(WithWeakRefs())
+	as := NewListenersWith(WithWeakRefs())
+	assert.Nil(t, ls.Put("put", NewListener()))
+	assert.Nil(t, as.Put("put", NewListener()))
+	func() {
+		ls.GetOrCreate("gone")
+		as.GetOrCreate("gone")
+	}()
+
+	collect(t, func() bool {
+		return ls.Len() == 1 && as.Len() == 1
+	})
+	_, found := as.Get("put")
+	assert.True(t, found)
+	li, found := ls.Get("put")
+	assert.True(t, found)
+	li.Broadcast("foo")
+
+	li = nil
+	ls.Delete("put")
+	_, found = ls.Get("put")
+	assert.False(t, found)
+	assert.Equal(t, 0, ls.Len())
+}
@@ -0,0 +1,40 @@
+//go:build go1.9
+// +build go1.9
+
+package listener_test
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/jenchik/listener"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenersPutKeeps(t *testing.T) {
+	ls := NewListeners()
+	li1, _ := ls.GetOrCreate("key1")
+	assert.True(t, ls.Put("key1", NewListener()) == li1)
+	liX, _ := ls.Get("key1")
+	assert.True(t, liX == li1)
+}
+
+func TestListenersDeleteOnce(t *testing.T) {
+	m := &countMetrics{}
+	ls := NewListenersWith(WithMetrics(m))
+
+	for i := 0; i < 100; i++ {
+		ls.GetOrCreate(i)
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ls.Delete(i)
+			}()
+		}
+		wg.Wait()
+	}
+	assert.Equal(t, 100, m.deletes)
+	assert.Equal(t, 0, ls.Len())
+}
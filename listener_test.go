@@ -1,12 +1,16 @@
 package listener_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"runtime"
 	"sync"
 	"testing"
 	"time"
 
 	. "github.com/jenchik/listener"
+	"github.com/jenchik/listener/listenertest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -306,3 +310,1129 @@ func TestListenersWithGC(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, *pT[0][0][0][0][0][0][0][0][0][0], 75305)
 }
+
+func waitWaiters(li Listener, n int) {
+	in := li.(Inspector)
+	for in.Waiters() != n {
+		runtime.Gosched()
+	}
+}
+
+func TestListenerInspect(t *testing.T) {
+	for _, li := range []Listener{NewListener(), NewListenerOnce()} {
+		in := li.(Inspector)
+		assert.Equal(t, StatePending, in.State())
+		assert.True(t, in.FiredAt().IsZero())
+		assert.Equal(t, uint64(0), in.BroadcastCount())
+
+		done := make(chan interface{})
+		go func() {
+			done <- li.Wait()
+		}()
+		waitWaiters(li, 1)
+		assert.Equal(t, 1, in.Waiters())
+
+		start := time.Now()
+		li.Broadcast("foo")
+		assert.Equal(t, "foo", <-done)
+		assert.Equal(t, 0, in.Waiters())
+		assert.Equal(t, StateFired, in.State())
+		assert.False(t, in.FiredAt().Before(start))
+
+		fired := in.FiredAt()
+		li.Broadcast("bar")
+		assert.Equal(t, uint64(2), in.BroadcastCount())
+		assert.Equal(t, fired, in.FiredAt())
+
+		li.(Closer).Close(nil)
+		assert.Equal(t, StateClosed, in.State())
+		li.Broadcast("baz")
+		assert.Equal(t, uint64(3), in.BroadcastCount())
+		value, found := li.Receive()
+		assert.True(t, found)
+		assert.NotEqual(t, "baz", value)
+	}
+}
+
+func TestListenerClose(t *testing.T) {
+	for _, li := range []Listener{NewListener(), NewListenerOnce()} {
+		done := make(chan interface{})
+		go func() {
+			done <- li.Wait()
+		}()
+		waitWaiters(li, 1)
+
+		li.(Closer).Close(nil)
+		assert.Equal(t, ErrClosed, <-done)
+		assert.Equal(t, StateClosed, li.(Inspector).State())
+
+		li.Broadcast(123)
+		assert.Equal(t, ErrClosed, li.Wait())
+	}
+}
+
+func TestListenersStats(t *testing.T) {
+	ls := NewStringListeners()
+
+	li1, _ := ls.GetOrCreate("key1")
+	li2, _ := ls.GetOrCreate("key2")
+	li3, _ := ls.GetOrCreate("key3")
+	ls.GetOrCreate("key4")
+
+	go li1.Wait()
+	waitWaiters(li1, 1)
+	li2.Broadcast(1)
+	li2.Broadcast(2)
+	li3.(Closer).Close(nil)
+
+	s := ls.Stats()
+	assert.Equal(t, 4, s.Keys)
+	assert.Equal(t, 2, s.Pending)
+	assert.Equal(t, 1, s.Fired)
+	assert.Equal(t, 1, s.Closed)
+	assert.Equal(t, 1, s.Stuck)
+	assert.Equal(t, 1, s.Waiters)
+	assert.Equal(t, uint64(2), s.Broadcasts)
+
+	li1.Broadcast(nil)
+	waitWaiters(li1, 0)
+	assert.Equal(t, Stats{Keys: 4, Pending: 1, Fired: 2, Closed: 1, Broadcasts: 3}, ls.Stats())
+}
+
+type countMetrics struct {
+	mu                                         sync.Mutex
+	created, hits, misses, deletes, broadcasts int
+	waiters, waits                             int
+}
+
+func (m *countMetrics) inc(p *int, n int) {
+	m.mu.Lock()
+	*p += n
+	m.mu.Unlock()
+}
+
+func (m *countMetrics) Created()                 { m.inc(&m.created, 1) }
+func (m *countMetrics) Hit()                     { m.inc(&m.hits, 1) }
+func (m *countMetrics) Miss()                    { m.inc(&m.misses, 1) }
+func (m *countMetrics) Deleted()                 { m.inc(&m.deletes, 1) }
+func (m *countMetrics) Broadcast()               { m.inc(&m.broadcasts, 1) }
+func (m *countMetrics) WaitStarted()             { m.inc(&m.waiters, 1) }
+func (m *countMetrics) WaitDone(d time.Duration) { m.inc(&m.waiters, -1); m.inc(&m.waits, 1) }
+
+func TestListenersMetrics(t *testing.T) {
+	m := new(countMetrics)
+	ls := NewListenersWith(WithMetrics(m), WithCreater(NewListenerOnce))
+
+	li1, found := ls.GetOrCreate("key1")
+	assert.False(t, found)
+	li2, found := ls.GetOrCreate("key1")
+	assert.True(t, found)
+	assert.True(t, li1 == li2)
+	ls.GetOrCreate(2)
+
+	go li1.Broadcast("foo")
+	assert.Equal(t, "foo", li1.Wait())
+	li1.Broadcast("bar")
+
+	ls.Delete("key1")
+	ls.Delete("key3")
+
+	assert.Equal(t, 2, m.created)
+	assert.Equal(t, 1, m.hits)
+	assert.Equal(t, 2, m.misses)
+	assert.Equal(t, 1, m.deletes)
+	assert.Equal(t, 2, m.broadcasts)
+	assert.Equal(t, 0, m.waiters)
+	assert.Equal(t, 1, m.waits)
+
+	assert.Equal(t, StateFired, li1.(Inspector).State())
+	assert.Equal(t, Stats{Keys: 1, Pending: 1}, ls.Stats())
+}
+
+func TestIntStringListenersMetrics(t *testing.T) {
+	m := new(countMetrics)
+	ls1 := NewIntListenersWith(WithMetrics(m))
+	ls2 := NewStringListenersWith(WithMetrics(m))
+
+	ls1.GetOrCreate(1)
+	ls1.GetOrCreate(1)
+	ls2.GetOrCreate("1")
+	ls2.GetOrCreate("2")
+	ls1.Delete(1)
+	ls2.Delete("1")
+	ls2.Delete("1")
+
+	assert.Equal(t, 3, m.created)
+	assert.Equal(t, 1, m.hits)
+	assert.Equal(t, 3, m.misses)
+	assert.Equal(t, 2, m.deletes)
+}
+
+func TestListenersInterceptors(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	event := func(s string) {
+		mu.Lock()
+		events = append(events, s)
+		mu.Unlock()
+	}
+	errOdd := errors.New("odd value")
+
+	ls := NewIntListenersWith(
+		WithCreater(NewListenerOnce),
+		WithInterceptors(
+			InterceptorFuncs{
+				Create: func(key interface{}, li Listener) {
+					event(fmt.Sprint("create ", key))
+				},
+				Broadcast: func(key interface{}, value interface{}) (interface{}, error) {
+					if value.(int)%2 != 0 {
+						return nil, errOdd
+					}
+					return value.(int) * 10, nil
+				},
+			},
+			InterceptorFuncs{
+				Broadcast: func(key interface{}, value interface{}) (interface{}, error) {
+					return value.(int) + 1, nil
+				},
+				Wake: func(key interface{}, value interface{}) {
+					event(fmt.Sprint("wake ", key, " ", value))
+				},
+				Delete: func(key interface{}, li Listener) {
+					event(fmt.Sprint("delete ", key))
+				},
+			},
+		),
+	)
+
+	li, _ := ls.GetOrCreate(7)
+	ls.GetOrCreate(7)
+
+	assert.Equal(t, errOdd, TryBroadcast(li, 3))
+	li.Broadcast(5)
+	_, found := li.Receive()
+	assert.False(t, found)
+	assert.Equal(t, StatePending, li.(Inspector).State())
+
+	assert.NoError(t, TryBroadcast(li, 4))
+	assert.Equal(t, 41, li.Wait())
+
+	ls.Delete(7)
+	ls.Delete(7)
+
+	assert.Equal(t, []string{"create 7", "wake 7 41", "delete 7"}, events)
+	assert.NoError(t, TryBroadcast(NewListener(), 1))
+}
+
+func TestListenersWatchdog(t *testing.T) {
+	clock := listenertest.NewClock(time.Now())
+	var reported []StuckWaiter
+	var mu sync.Mutex
+	w := NewWatchdog(time.Minute, func(sw StuckWaiter) {
+		mu.Lock()
+		reported = append(reported, sw)
+		mu.Unlock()
+	}, WatchdogOptions{Clock: clock, Stack: true})
+
+	ls := NewIntListenersWith(WithName("jobs"), WithWatchdog(w))
+	li1, _ := ls.GetOrCreate(1)
+	li2, _ := ls.GetOrCreate(2)
+
+	done1 := make(chan struct{})
+	go func() {
+		li1.Wait()
+		close(done1)
+	}()
+	waitWaiters(li1, 1)
+
+	clock.Advance(30 * time.Second)
+	done2 := make(chan struct{})
+	go func() {
+		li2.Wait()
+		close(done2)
+	}()
+	waitWaiters(li2, 1)
+
+	assert.Len(t, w.Check(), 0)
+
+	clock.Advance(45 * time.Second)
+	stuck := w.Check()
+	assert.Len(t, stuck, 1)
+	assert.Len(t, w.Check(), 1)
+	assert.Len(t, reported, 1)
+	assert.Equal(t, "jobs", reported[0].Registry)
+	assert.Equal(t, 1, reported[0].Key)
+	assert.Equal(t, 75*time.Second, reported[0].Waited)
+	assert.Contains(t, string(reported[0].Stack), "TestListenersWatchdog")
+
+	li1.Broadcast(nil)
+	<-done1
+	clock.Advance(time.Hour)
+	stuck = w.Check()
+	assert.Len(t, stuck, 1)
+	assert.Equal(t, 2, stuck[0].Key)
+	assert.Len(t, reported, 2)
+
+	li2.Broadcast(nil)
+	<-done2
+	assert.Len(t, w.Check(), 0)
+}
+
+func TestPromise(t *testing.T) {
+	r, li := NewPromise()
+	go r.Resolve("ok")
+	assert.Equal(t, "ok", li.Wait())
+	r.Release()
+	r.Resolve("again")
+	assert.Equal(t, "ok", li.Wait())
+	assert.Equal(t, StateFired, li.(Inspector).State())
+
+	var abandoned []Abandoned
+	r, li = NewPromise(PromiseOptions{
+		OnAbandoned: func(a Abandoned) {
+			abandoned = append(abandoned, a)
+		},
+		Stack: true,
+	})
+	func() {
+		defer r.Release()
+	}()
+	assert.Equal(t, ErrAbandoned, li.Wait())
+	assert.Equal(t, StateClosed, li.(Inspector).State())
+	assert.Len(t, abandoned, 1)
+	assert.False(t, abandoned[0].Collected)
+	assert.Contains(t, string(abandoned[0].Stack), "TestPromise")
+	r.Resolve("late")
+	assert.Equal(t, ErrAbandoned, li.Wait())
+
+	_, isListener := li.(Listener)
+	assert.False(t, isListener)
+}
+
+func TestPromiseCollected(t *testing.T) {
+	reported := make(chan Abandoned, 1)
+	SetAbandonedHandler(func(a Abandoned) {
+		reported <- a
+	})
+	defer SetAbandonedHandler(nil)
+
+	li := func() Receiver {
+		_, li := NewPromise()
+		return li
+	}()
+
+	done := make(chan interface{})
+	go func() {
+		done <- li.Wait()
+	}()
+
+	for {
+		runtime.GC()
+		select {
+		case v := <-done:
+			assert.Equal(t, ErrAbandoned, v)
+			assert.True(t, (<-reported).Collected)
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestListenersGetReceiver(t *testing.T) {
+	ls := NewListeners()
+	ils := NewIntListeners()
+	sls := NewStringListeners(NewListenerOnce)
+
+	r, found := ls.GetReceiver("key1")
+	assert.Nil(t, r)
+	assert.False(t, found)
+
+	li1, _ := ls.GetOrCreate("key1")
+	li2, _ := ils.GetOrCreate(1)
+	li3, _ := sls.GetOrCreate("key1")
+
+	r1, _ := ls.GetReceiver("key1")
+	r2, _ := ils.GetReceiver(1)
+	r3, found := sls.GetReceiver("key1")
+	assert.True(t, found)
+
+	for i, r := range []Receiver{r1, r2, r3} {
+		_, ok := r.(Broadcaster)
+		assert.False(t, ok)
+		_, ok = r.(Listener)
+		assert.False(t, ok)
+
+		[]Listener{li1, li2, li3}[i].Broadcast(i)
+		assert.Equal(t, i, r.Wait())
+		value, found := r.Receive()
+		assert.True(t, found)
+		assert.Equal(t, i, value)
+		assert.Equal(t, StateFired, r.(Inspector).State())
+	}
+
+	var b Broadcaster = li1
+	b.Broadcast("foo")
+	assert.Equal(t, "foo", r1.Wait())
+}
+
+func TestListenerSpin(t *testing.T) {
+	li := NewListenerWith(WithSpin(16))
+
+	done := make(chan interface{})
+	go func() {
+		done <- li.Wait()
+	}()
+	waitWaiters(li, 1)
+	li.Broadcast("foo")
+	assert.Equal(t, "foo", <-done)
+	assert.Equal(t, "foo", li.Wait())
+
+	// a waiter that outlasts its spin budget parks
+	li = NewListenerWith(WithSpin(4))
+	go func() {
+		done <- li.Wait()
+	}()
+	waitWaiters(li, 1)
+	time.Sleep(10 * time.Millisecond)
+	li.Broadcast("bar")
+	assert.Equal(t, "bar", <-done)
+}
+
+func TestListenersPool(t *testing.T) {
+	ls := NewStringListenersWith(WithOncePool())
+
+	li, found := ls.GetOrCreate("a")
+	assert.False(t, found)
+	li.Broadcast("foo")
+	li.Broadcast("bar")
+	ls.Delete("a")
+
+	// the core is recycled; the stale handle keeps its final state
+	li2, found := ls.GetOrCreate("a")
+	assert.False(t, found)
+	_, ok := li2.Receive()
+	assert.False(t, ok)
+	li.Broadcast("baz")
+	value, ok := li.Receive()
+	assert.True(t, ok)
+	assert.Equal(t, "foo", value)
+	assert.Equal(t, "foo", li.Wait())
+	assert.Equal(t, StateFired, li.(Inspector).State())
+	assert.Equal(t, uint64(2), li.(Inspector).BroadcastCount())
+	_, ok = li2.Receive()
+	assert.False(t, ok)
+
+	// deleted while pending: waiters and late broadcasts still work
+	done := make(chan interface{})
+	go func() {
+		done <- li2.Wait()
+	}()
+	waitWaiters(li2, 1)
+	ls.Delete("a")
+	li2.Broadcast("qux")
+	assert.Equal(t, "qux", <-done)
+	li2.Broadcast("quux")
+	assert.Equal(t, "qux", li2.Wait())
+	assert.Equal(t, 0, li2.(Inspector).Waiters())
+
+	rs := NewIntListenersWith(WithOncePool())
+	li, _ = rs.GetOrCreate(1)
+	li.Broadcast(1)
+	li.(Closer).Close(nil)
+	assert.Equal(t, StateClosed, li.(Inspector).State())
+	rs.Delete(1)
+	assert.Equal(t, StateClosed, li.(Inspector).State())
+	assert.Equal(t, 1, li.Wait())
+	value, err := WaitUntil(context.Background(), li, func(v interface{}) bool { return v == 1 })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+	li, _ = rs.GetOrCreate(1)
+	li.Broadcast(2)
+	_, err = WaitUntil(context.Background(), li, func(v interface{}) bool { return v == 1 })
+	assert.Equal(t, ErrNoMatch, err)
+
+	// WaitUntilKey waits on the pooled listener and gives up with ctx
+	done = make(chan interface{})
+	go func() {
+		value, err := rs.WaitUntilKey(context.Background(), 2, func(v interface{}) bool { return v == "ok" })
+		assert.NoError(t, err)
+		done <- value
+	}()
+	li, _ = rs.GetOrCreate(2)
+	waitWaiters(li, 1)
+	li.Broadcast("ok")
+	assert.Equal(t, "ok", <-done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = rs.WaitUntilKey(ctx, 3, func(interface{}) bool { return true })
+	assert.Equal(t, context.DeadlineExceeded, err)
+	li, _ = rs.Get(3)
+	assert.Equal(t, 0, li.(Inspector).Waiters())
+
+	// the pool replaces any creater, whatever the order of the options
+	for _, opts := range [][]Option{
+		{WithCreater(NewListener), WithOncePool()},
+		{WithOncePool(), WithCreater(NewListener)},
+	} {
+		rs := NewIntListenersWith(opts...)
+		li, _ := rs.GetOrCreate(1)
+		li.Broadcast(1)
+		li.Broadcast(2)
+		assert.Equal(t, 1, li.Wait())
+		rs.Delete(1)
+		li2, _ := rs.GetOrCreate(1)
+		_, ok := li2.Receive()
+		assert.False(t, ok)
+		assert.Equal(t, 1, li.Wait())
+	}
+}
+
+func TestListenersPoolConcurrent(t *testing.T) {
+	ls := NewIntListenersWith(WithOncePool())
+
+	const workers, cycles = 8, 300
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < cycles; i++ {
+				key := i % 10
+				li, found := ls.GetOrCreate(key)
+				if !found {
+					li.Broadcast(key)
+					ls.Delete(key)
+				}
+				if v := li.Wait(); v != key {
+					t.Errorf("key %d: got %v", key, v)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestListenerTTL(t *testing.T) {
+	clock := listenertest.NewClock(time.Unix(1000, 0))
+	li := NewListenerWith(WithTTL(time.Second), WithClock(clock))
+	in := li.(Inspector)
+	assert.Equal(t, time.Unix(1000, 0), in.CreatedAt())
+
+	li.Broadcast("foo")
+	assert.Equal(t, "foo", li.Wait())
+	clock.Advance(999 * time.Millisecond)
+	value, ok := li.Receive()
+	assert.True(t, ok)
+	assert.Equal(t, "foo", value)
+
+	clock.Advance(time.Millisecond)
+	_, ok = li.Receive()
+	assert.False(t, ok)
+	assert.Equal(t, StatePending, in.State())
+
+	// Wait blocks again until a fresh value arrives
+	done := make(chan interface{})
+	go func() {
+		done <- li.Wait()
+	}()
+	waitWaiters(li, 1)
+	select {
+	case <-done:
+		t.Fatal("woke on a stale value")
+	case <-time.After(10 * time.Millisecond):
+	}
+	BroadcastTTL(li, "bar", time.Minute)
+	assert.Equal(t, "bar", <-done)
+	clock.Advance(time.Hour)
+	_, ok = li.Receive()
+	assert.False(t, ok)
+
+	// a non-positive ttl never expires
+	BroadcastTTL(li, "baz", 0)
+	clock.Advance(time.Hour)
+	assert.Equal(t, "baz", li.Wait())
+	assert.Equal(t, uint64(3), in.BroadcastCount())
+	assert.Equal(t, time.Unix(1000, 0).Add(time.Hour+time.Second), in.FiredAt())
+
+	// closing keeps a fresh value for good and replaces a stale one
+	li.Broadcast("qux")
+	li.(Closer).Close(nil)
+	clock.Advance(time.Hour)
+	assert.Equal(t, "qux", li.Wait())
+	assert.Equal(t, StateClosed, in.State())
+
+	li = NewListenerWith(WithTTL(time.Second), WithClock(clock))
+	li.Broadcast("foo")
+	clock.Advance(time.Second)
+	li.(Closer).Close(nil)
+	assert.Equal(t, ErrClosed, li.Wait())
+
+	ls := NewListenersWith(WithMetrics(new(countMetrics)), WithCreater(func() Listener {
+		return NewListenerWith(WithClock(clock))
+	}))
+	li, _ = ls.GetOrCreate("key")
+	BroadcastTTL(li, "foo", time.Second)
+	clock.Advance(time.Second)
+	_, ok = li.Receive()
+	assert.False(t, ok)
+}
+
+func TestListenersBroadcastAfter(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := listenertest.NewClock(start)
+	ls := NewStringListenersWith(WithTimerWheel(NewTimerWheel(time.Millisecond, clock)))
+
+	timeout := errors.New("timeout")
+	ls.BroadcastAfter("a", 5*time.Millisecond, timeout)
+	tb := ls.BroadcastAt("b", start.Add(time.Hour), "late")
+	ls.BroadcastAfter("c", 10*time.Hour, "later")
+	a, _ := ls.GetOrCreate("a")
+	b, _ := ls.GetOrCreate("b")
+	c, _ := ls.GetOrCreate("c")
+
+	clock.Advance(4 * time.Millisecond)
+	_, ok := a.Receive()
+	assert.False(t, ok)
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, timeout, a.Wait())
+
+	assert.True(t, tb.Stop())
+	assert.False(t, tb.Stop())
+	clock.Advance(2 * time.Hour)
+	_, ok = b.Receive()
+	assert.False(t, ok)
+	_, ok = c.Receive()
+	assert.False(t, ok)
+	clock.Advance(8 * time.Hour)
+	assert.Equal(t, "later", c.Wait())
+	assert.Equal(t, 0, clock.Pending())
+
+	// the shared default wheel runs on the system clock
+	is := NewIntListeners()
+	is.BroadcastAfter(1, 5*time.Millisecond, "foo")
+	li, _ := is.GetOrCreate(1)
+	assert.Equal(t, "foo", li.Wait())
+}
+
+func TestTimerWheel(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := listenertest.NewClock(start)
+	w := NewTimerWheel(time.Millisecond, clock)
+
+	// spread over every level, including past the wheel's span
+	delays := []time.Duration{0, 1, 63, 64, 65, 4095, 4096, 4097, 262143, 262144, 16777215, 16777216, 20000000}
+	for i := 0; i < 200; i++ {
+		delays = append(delays, time.Duration((i*7919)%300000))
+	}
+
+	fired := make([]time.Time, len(delays))
+	var timers []Timer
+	for i, d := range delays {
+		i, d := i, d*time.Millisecond
+		delays[i] = d
+		timers = append(timers, w.AfterFunc(d, func() {
+			fired[i] = clock.Now()
+		}))
+	}
+	assert.True(t, timers[5].Stop())
+
+	for elapsed := time.Duration(0); elapsed < 6*time.Hour; elapsed += 7 * time.Minute {
+		clock.Advance(7 * time.Minute)
+	}
+	for i, d := range delays {
+		if i == 5 {
+			assert.True(t, fired[i].IsZero())
+			continue
+		}
+		if late := fired[i].Sub(start.Add(d)); late < 0 || late > time.Millisecond {
+			t.Errorf("timer %d (%v) fired %v late", i, d, late)
+		}
+	}
+}
+
+func TestDeadlineListener(t *testing.T) {
+	clock := listenertest.NewClock(time.Unix(1000, 0))
+	wheel := NewTimerWheel(time.Millisecond, clock)
+
+	li := NewDeadlineListener(time.Second, DeadlineOptions{Wheel: wheel})
+	done := make(chan interface{})
+	go func() {
+		done <- li.Wait()
+	}()
+	waitWaiters(li, 1)
+	clock.Advance(999 * time.Millisecond)
+	_, ok := li.Receive()
+	assert.False(t, ok)
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, context.DeadlineExceeded, <-done)
+	li.Broadcast("late")
+	assert.Equal(t, context.DeadlineExceeded, li.Wait())
+
+	// a real value releases the timer
+	li = NewDeadlineListener(time.Second, DeadlineOptions{Wheel: wheel})
+	assert.Equal(t, 1, wheel.Len())
+	li.Broadcast("foo")
+	assert.Equal(t, 0, wheel.Len())
+	clock.Advance(time.Hour)
+	assert.Equal(t, "foo", li.Wait())
+
+	fallback := errors.New("no response")
+	ls := NewStringListenersWith(WithCreater(DeadlineCreater(time.Minute, DeadlineOptions{
+		Default: fallback,
+		Wheel:   wheel,
+	})))
+	a, _ := ls.GetOrCreate("a")
+	b, _ := ls.GetOrCreate("b")
+	b.(Closer).Close(nil)
+	assert.Equal(t, 1, wheel.Len())
+	clock.Advance(time.Minute)
+	assert.Equal(t, fallback, a.Wait())
+	assert.Equal(t, ErrClosed, b.Wait())
+	assert.Equal(t, StateClosed, b.(Inspector).State())
+}
+
+func TestReplayListener(t *testing.T) {
+	ctx := context.Background()
+	li := NewReplayListener(ReplayOptions{Size: 3})
+
+	_, ok := li.Receive()
+	assert.False(t, ok)
+	early, ok := Subscribe(li)
+	assert.True(t, ok)
+	for i := 1; i <= 5; i++ {
+		li.Broadcast(i)
+	}
+	assert.Equal(t, 5, li.Wait())
+
+	// a late subscriber replays the retained history, then follows
+	late, _ := Subscribe(li)
+	for _, want := range []int{3, 4, 5} {
+		value, err := late.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, want, value)
+	}
+	// the early one fell behind and skips to the oldest retained value
+	value, _ := early.Next(ctx)
+	assert.Equal(t, 3, value)
+
+	done := make(chan interface{})
+	go func() {
+		value, _ := late.Next(ctx)
+		done <- value
+	}()
+	waitWaiters(li, 1)
+	li.Broadcast(6)
+	assert.Equal(t, 6, <-done)
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	_, err := late.Next(tctx)
+	cancel()
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	li.(Closer).Close(nil)
+	li.Broadcast(7)
+	for _, want := range []int{4, 5, 6} {
+		value, err := early.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, want, value)
+	}
+	_, err = early.Next(ctx)
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, 6, li.Wait())
+	assert.Equal(t, StateClosed, li.(Inspector).State())
+
+	late.Close()
+	_, err = late.Next(ctx)
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestReplayListenerWindow(t *testing.T) {
+	ctx := context.Background()
+	clock := listenertest.NewClock(time.Unix(1000, 0))
+	ls := NewStringListenersWith(WithCreater(ReplayCreater(ReplayOptions{
+		Window: time.Minute,
+		Clock:  clock,
+	})))
+
+	li, _ := ls.GetOrCreate("status")
+	li.Broadcast("starting")
+	clock.Advance(time.Minute)
+	li.Broadcast("ready")
+	clock.Advance(time.Second)
+	li.Broadcast("degraded")
+
+	sub, ok := Subscribe(li)
+	assert.True(t, ok)
+	for _, want := range []string{"ready", "degraded"} {
+		value, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, want, value)
+	}
+
+	_, ok = Subscribe(NewListener())
+	assert.False(t, ok)
+}
+
+func TestRateLimitedListeners(t *testing.T) {
+	clock := listenertest.NewClock(time.Unix(1000, 0))
+	opts := RateOptions{Clock: clock}
+	received := func(li Listener) interface{} {
+		value, _ := li.Receive()
+		return value
+	}
+
+	// debounce: only the value that settles for the quiet period
+	li := NewDebounced(NewListener(), 100*time.Millisecond, opts)
+	for i := 1; i <= 5; i++ {
+		li.Broadcast(i)
+		clock.Advance(50 * time.Millisecond)
+	}
+	assert.Nil(t, received(li))
+	clock.Advance(50 * time.Millisecond)
+	assert.Equal(t, 5, li.Wait())
+	assert.Equal(t, uint64(1), li.(Inspector).BroadcastCount())
+
+	li = NewDebounced(NewListener(), 100*time.Millisecond, RateOptions{Clock: clock, Edges: EdgeLeading})
+	li.Broadcast(1)
+	li.Broadcast(2)
+	assert.Equal(t, 1, received(li))
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, received(li))
+
+	// throttle: first value of an interval at once, last at its end
+	li = NewThrottled(NewListener(), 100*time.Millisecond, opts)
+	li.Broadcast(1)
+	assert.Equal(t, 1, received(li))
+	li.Broadcast(2)
+	li.Broadcast(3)
+	assert.Equal(t, 1, received(li))
+	clock.Advance(100 * time.Millisecond)
+	assert.Equal(t, 3, received(li))
+	li.Broadcast(4)
+	assert.Equal(t, 3, received(li))
+	clock.Advance(100 * time.Millisecond)
+	assert.Equal(t, 4, received(li))
+	clock.Advance(100 * time.Millisecond)
+	li.Broadcast(5)
+	assert.Equal(t, 5, received(li))
+	assert.Equal(t, uint64(4), li.(Inspector).BroadcastCount())
+
+	li = NewThrottled(NewListener(), 100*time.Millisecond, RateOptions{Clock: clock, Edges: EdgeLeading})
+	li.Broadcast(1)
+	li.Broadcast(2)
+	clock.Advance(100 * time.Millisecond)
+	assert.Equal(t, 1, received(li))
+
+	// sample: the latest value every interval, if there is a new one
+	ls := NewStringListenersWith(WithCreater(SampleCreater(NewListener, 100*time.Millisecond, opts)))
+	li, _ = ls.GetOrCreate("key")
+	for i := 1; i <= 25; i++ {
+		li.Broadcast(i)
+		clock.Advance(10 * time.Millisecond)
+		if i == 10 {
+			assert.Equal(t, 10, received(li))
+		}
+	}
+	assert.Equal(t, 20, received(li))
+	clock.Advance(time.Second)
+	assert.Equal(t, 25, received(li))
+	assert.Equal(t, uint64(3), li.(Inspector).BroadcastCount())
+	assert.Equal(t, 0, clock.Pending())
+
+	// per key via Put; Close flushes the trailing value
+	li = NewDebounced(NewListenerOnce(), time.Second, opts)
+	ls.Put("put", li)
+	li.Broadcast("foo")
+	li.(Closer).Close(nil)
+	assert.Equal(t, "foo", li.Wait())
+	assert.Equal(t, StateClosed, li.(Inspector).State())
+	assert.Equal(t, 0, clock.Pending())
+
+	// nested wrappers each flush their held value
+	li = NewThrottled(NewDebounced(NewListener(), time.Second, opts), time.Second, opts)
+	li.Broadcast(1)
+	li.Broadcast(2)
+	li.(Closer).Close(nil)
+	assert.Equal(t, 2, received(li))
+	assert.Equal(t, StateClosed, li.(Inspector).State())
+	assert.Equal(t, 0, clock.Pending())
+
+	// broadcasts after Close are dropped
+	li.Broadcast(3)
+	assert.Equal(t, 2, received(li))
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestBatchedListener(t *testing.T) {
+	ctx := context.Background()
+	clock := listenertest.NewClock(time.Unix(1000, 0))
+	ls := NewStringListenersWith(WithCreater(BatchCreater(
+		ReplayCreater(ReplayOptions{Size: 100}),
+		BatchOptions{Size: 3, Every: time.Second, Clock: clock},
+	)))
+
+	li, _ := ls.GetOrCreate("metrics")
+	sub, ok := Subscribe(li)
+	assert.True(t, ok)
+	next := func() interface{} {
+		value, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		return value
+	}
+
+	// full batches flush at once
+	for i := 1; i <= 7; i++ {
+		li.Broadcast(i)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, next())
+	assert.Equal(t, []interface{}{4, 5, 6}, next())
+	assert.Equal(t, []interface{}{4, 5, 6}, li.Wait())
+
+	// partial ones when their window ends
+	clock.Advance(999 * time.Millisecond)
+	li.Broadcast(8)
+	assert.Equal(t, []interface{}{4, 5, 6}, li.Wait())
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, []interface{}{7, 8}, next())
+
+	li.Broadcast(9)
+	li.Broadcast(10)
+	li.Broadcast(11)
+	assert.Equal(t, []interface{}{9, 10, 11}, next())
+	clock.Advance(time.Hour)
+	assert.Equal(t, 0, clock.Pending())
+
+	// and the rest on close
+	li.Broadcast(12)
+	li.(Closer).Close(nil)
+	assert.Equal(t, []interface{}{12}, next())
+	_, err := sub.Next(ctx)
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, 0, clock.Pending())
+
+	// broadcasts after Close are dropped
+	li.Broadcast(13)
+	assert.Equal(t, 0, clock.Pending())
+	clock.Advance(time.Hour)
+	assert.Equal(t, uint64(5), li.(Inspector).BroadcastCount())
+
+	li = NewBatched(NewListener(), BatchOptions{})
+	li.Broadcast("foo")
+	assert.Equal(t, []interface{}{"foo"}, li.Wait())
+
+	// closing an outer wrapper flushes the batch too
+	li = NewThrottled(NewBatched(NewListener(), BatchOptions{Size: 10}), time.Second, RateOptions{Clock: clock})
+	li.Broadcast(1)
+	li.Broadcast(2)
+	li.(Closer).Close(nil)
+	assert.Equal(t, []interface{}{1, 2}, li.Wait())
+	assert.Equal(t, StateClosed, li.(Inspector).State())
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestListenerWaitUntil(t *testing.T) {
+	ctx := context.Background()
+	atLeast := func(n int) func(interface{}) bool {
+		return func(value interface{}) bool {
+			return value.(int) >= n
+		}
+	}
+
+	li := NewListener()
+	done := make(chan interface{})
+	go func() {
+		value, err := WaitUntil(ctx, li, atLeast(3))
+		assert.NoError(t, err)
+		done <- value
+	}()
+	waitWaiters(li, 1)
+	for i := 1; i <= 4; i++ {
+		li.Broadcast(i)
+		if i < 3 {
+			select {
+			case value := <-done:
+				t.Fatalf("woke on %v", value)
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}
+	value := <-done
+	assert.True(t, value == 3 || value == 4)
+
+	value, err := WaitUntil(ctx, li, atLeast(4))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, value)
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	_, err = WaitUntil(tctx, li, atLeast(10))
+	cancel()
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 0, li.(Inspector).Waiters())
+
+	go func() {
+		_, err := WaitUntil(ctx, li, atLeast(10))
+		done <- err
+	}()
+	waitWaiters(li, 1)
+	li.(Closer).Close(nil)
+	assert.Equal(t, ErrClosed, <-done)
+
+	// registries wait on keys that do not exist yet
+	ls := NewStringListenersWith(WithMetrics(new(countMetrics)))
+	go func() {
+		value, err := ls.WaitUntilKey(ctx, "replicas", atLeast(3))
+		assert.NoError(t, err)
+		done <- value
+	}()
+	for {
+		if li, found := ls.Get("replicas"); found && li.(Inspector).Waiters() == 1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	li, _ = ls.GetOrCreate("replicas")
+	li.Broadcast(1)
+	li.Broadcast(3)
+	assert.Equal(t, 3, <-done)
+
+	r, _ := ls.GetReceiver("replicas")
+	value, err = WaitUntil(ctx, r, atLeast(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, value)
+
+	once := NewListenerOnce()
+	once.Broadcast(1)
+	_, err = WaitUntil(ctx, once, atLeast(2))
+	assert.Equal(t, ErrNoMatch, err)
+	value, err = WaitUntil(ctx, once, atLeast(1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestListenerUpdate(t *testing.T) {
+	li := NewListener()
+	u := li.(Updater)
+
+	assert.False(t, u.CompareAndBroadcast(1, 2))
+	assert.True(t, u.CompareAndBroadcast(nil, 1))
+	assert.False(t, u.CompareAndBroadcast(nil, 2))
+	assert.True(t, u.CompareAndBroadcast(1, 2))
+	value, ok := li.Receive()
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+
+	u.Update(func(old interface{}, ok bool) interface{} {
+		assert.True(t, ok)
+		return old.(int) * 10
+	})
+	assert.Equal(t, 20, li.Wait())
+	assert.Equal(t, uint64(3), li.(Inspector).BroadcastCount())
+
+	const n, workers = 200, 4
+	counter := NewListener()
+	seen := make(chan []int)
+	go func() {
+		var values []int
+		_, err := WaitUntil(context.Background(), counter, func(value interface{}) bool {
+			values = append(values, value.(int))
+			return value.(int) == n*workers
+		})
+		assert.NoError(t, err)
+		seen <- values
+	}()
+	waitWaiters(counter, 1)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				assert.NoError(t, Update(counter, func(old interface{}, ok bool) interface{} {
+					if !ok {
+						return 1
+					}
+					return old.(int) + 1
+				}))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, n*workers, counter.Wait())
+
+	values := <-seen
+	for i := 1; i < len(values); i++ {
+		assert.True(t, values[i-1] < values[i])
+	}
+
+	counter.(Closer).Close(errors.New("closed"))
+	assert.NoError(t, Update(counter, func(interface{}, bool) interface{} { return 0 }))
+	assert.Equal(t, n*workers, counter.Wait())
+
+	_, err := CompareAndBroadcast(NewListenerOnce(), nil, 1)
+	assert.Equal(t, ErrUnsupported, err)
+
+	// wrappers that change what is broadcast are not bypassed
+	throttled := NewThrottled(NewListener(), time.Hour)
+	err = Update(throttled, func(interface{}, bool) interface{} { return 1 })
+	assert.Equal(t, ErrUnsupported, err)
+	_, ok = throttled.Receive()
+	assert.False(t, ok)
+	ls := NewIntListenersWith(WithMetrics(new(countMetrics)), WithCreater(BatchCreater(NewListener, BatchOptions{Size: 2})))
+	batched, _ := ls.GetOrCreate(1)
+	_, err = CompareAndBroadcast(batched, nil, 1)
+	assert.Equal(t, ErrUnsupported, err)
+
+	// uncomparable values never match
+	m := map[string]int{"a": 1}
+	li = NewListener()
+	li.Broadcast(m)
+	fired, err := CompareAndBroadcast(li, m, 1)
+	assert.NoError(t, err)
+	assert.False(t, fired)
+	assert.False(t, li.(Updater).CompareAndBroadcast(m, 1))
+	assert.Equal(t, m, li.Wait())
+
+	type holder struct {
+		V interface{}
+	}
+	li.Broadcast(holder{m})
+	fired, err = CompareAndBroadcast(li, holder{m}, 1)
+	assert.NoError(t, err)
+	assert.False(t, fired)
+	assert.False(t, li.(Updater).CompareAndBroadcast(holder{m}, 1))
+	assert.Equal(t, holder{m}, li.Wait())
+	li.Broadcast(holder{1})
+	assert.True(t, li.(Updater).CompareAndBroadcast(holder{1}, 2))
+}
+
+func TestListenersUpdate(t *testing.T) {
+	veto := errors.New("negative")
+	l := NewIntListenersWith(WithInterceptors(InterceptorFuncs{
+		Broadcast: func(key interface{}, value interface{}) (interface{}, error) {
+			if value.(int) < 0 {
+				return nil, veto
+			}
+			return value, nil
+		},
+	}))
+
+	li, _ := l.GetOrCreate(1)
+	ok, err := CompareAndBroadcast(li, nil, 5)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	err = Update(li, func(old interface{}, ok bool) interface{} {
+		return old.(int) - 10
+	})
+	assert.Equal(t, veto, err)
+	assert.Equal(t, 5, li.Wait())
+
+	ok, err = CompareAndBroadcast(li, 4, 6)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), l.Stats().Broadcasts)
+}
@@ -1,14 +1,29 @@
 package listener
 
+import (
+	"sync/atomic"
+	"time"
+)
+
 type (
 	listenerOnce struct {
-		done  chan struct{}
-		value interface{}
+		count   uint64
+		created int64
+		fired   int64
+		done    chan struct{}
+		value   interface{}
+		trigger uint32
+		closed  uint32
+		waiters int32
 	}
 )
 
 var (
-	_ Listener = &listenerOnce{}
+	_ Listener    = &listenerOnce{}
+	_ Broadcaster = &listenerOnce{}
+	_ Receiver    = &listenerOnce{}
+	_ Inspector   = &listenerOnce{}
+	_ Closer      = &listenerOnce{}
 )
 
 func NewListenerOnce() Listener {
@@ -17,15 +32,20 @@ func NewListenerOnce() Listener {
 
 func newListenerOnce() *listenerOnce {
 	return &listenerOnce{
-		done: make(chan struct{}),
+		done:    make(chan struct{}),
+		created: time.Now().UnixNano(),
 	}
 }
 
 func (l *listenerOnce) Broadcast(value interface{}) {
-	select {
-	case <-l.done:
-	default:
+	atomic.AddUint64(&l.count, 1)
+	l.resolve(value)
+}
+
+func (l *listenerOnce) resolve(value interface{}) {
+	if atomic.CompareAndSwapUint32(&l.trigger, 0, 1) {
 		l.value = value
+		atomic.StoreInt64(&l.fired, time.Now().UnixNano())
 		close(l.done)
 	}
 }
@@ -41,7 +61,56 @@ func (l *listenerOnce) Receive() (interface{}, bool) {
 }
 
 func (l *listenerOnce) Wait() interface{} {
-	<-l.done
+	select {
+	case <-l.done:
+	default:
+		atomic.AddInt32(&l.waiters, 1)
+		<-l.done
+		atomic.AddInt32(&l.waiters, -1)
+	}
 
 	return l.value
 }
+
+// Close resolves pending waiters with err (ErrClosed if nil) and drops
+// all further broadcasts. A value that has already fired is kept.
+func (l *listenerOnce) Close(err error) {
+	if !atomic.CompareAndSwapUint32(&l.closed, 0, 1) {
+		return
+	}
+
+	if err == nil {
+		err = ErrClosed
+	}
+	l.resolve(err)
+}
+
+func (l *listenerOnce) State() State {
+	if atomic.LoadUint32(&l.closed) != 0 {
+		return StateClosed
+	}
+
+	select {
+	case <-l.done:
+		return StateFired
+	default:
+	}
+
+	return StatePending
+}
+
+func (l *listenerOnce) Waiters() int {
+	return int(atomic.LoadInt32(&l.waiters))
+}
+
+func (l *listenerOnce) CreatedAt() time.Time {
+	return unixTime(l.created)
+}
+
+func (l *listenerOnce) FiredAt() time.Time {
+	return unixTime(atomic.LoadInt64(&l.fired))
+}
+
+func (l *listenerOnce) BroadcastCount() uint64 {
+	return atomic.LoadUint64(&l.count)
+}
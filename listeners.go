@@ -3,87 +3,208 @@
 package listener
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 type (
-	Listener interface {
+	Broadcaster interface {
 		Broadcast(value interface{})
+	}
+
+	Receiver interface {
 		Receive() (interface{}, bool)
 		Wait() interface{}
 	}
 
+	Listener interface {
+		Broadcaster
+		Receiver
+	}
+
 	Listeners struct {
-		creater func() Listener
-		lmap    map[interface{}]Listener
-		mu      sync.RWMutex
+		config
+		store anyStore
+	}
+
+	anyMapStore struct {
+		lmap map[interface{}]Listener
+		mu   sync.RWMutex
 	}
 )
 
 func NewListeners(creater ...func() Listener) *Listeners {
-	var c func() Listener = NewListener
-	if len(creater) != 0 && creater[0] != nil {
-		c = creater[0]
-	}
+	return NewListenersWith(firstCreater(creater))
+}
 
-	return &Listeners{
-		creater: c,
-		lmap:    make(map[interface{}]Listener, 8),
+func NewListenersWith(opts ...Option) *Listeners {
+	l := &Listeners{
+		config: newConfig(opts),
+	}
+	if l.backend.anyStore != nil {
+		l.store = l.backend.anyStore(&l.config)
+	} else {
+		l.store = &anyMapStore{
+			lmap: make(map[interface{}]Listener, 8),
+		}
 	}
+	if l.backend.anyBound != nil {
+		l.store = l.backend.anyBound(l.store, &l.config)
+	}
+
+	return l
 }
 
-func (l *Listeners) GetOrCreate(key interface{}) (li Listener, found bool) {
-	l.mu.RLock()
-	li, found = l.lmap[key]
-	l.mu.RUnlock()
+func (l *Listeners) GetOrCreate(key interface{}) (Listener, bool) {
+	li, found := l.store.load(key)
 	if !found {
-		l.mu.Lock()
-		li, found = l.lmap[key]
+		li, found = l.store.loadOrCreate(key, &l.config)
 		if !found {
-			li = l.creater()
-			l.lmap[key] = li
+			l.created(key, li)
+			return li, false
 		}
-		l.mu.Unlock()
+	}
+	l.metrics.Hit()
+	if l.keyed {
+		l.accessed(key, li)
 	}
 
-	return
+	return li, true
 }
 
-func (l *Listeners) Get(key interface{}) (li Listener, found bool) {
-	l.mu.RLock()
-	li, found = l.lmap[key]
-	l.mu.RUnlock()
+func (l *Listeners) Get(key interface{}) (Listener, bool) {
+	return l.store.load(key)
+}
 
-	return
+func (l *Listeners) GetReceiver(key interface{}) (Receiver, bool) {
+	if li, found := l.Get(key); found {
+		return ReadOnly(li), true
+	}
+
+	return nil, false
 }
 
 func (l *Listeners) Len() int {
-	return len(l.lmap)
+	return l.store.len()
 }
 
 func (l *Listeners) Delete(key interface{}) {
-	l.mu.Lock()
-	delete(l.lmap, key)
-	l.mu.Unlock()
+	if li, found := l.store.loadAndDelete(key); found {
+		l.metrics.Deleted()
+		if l.keyed {
+			l.deleted(key, li)
+		}
+		if l.pooled {
+			l.release(li)
+		}
+	}
 }
 
-func (l *Listeners) Put(key interface{}, li Listener) (old Listener) {
-	l.mu.Lock()
-	old = l.lmap[key]
-	if li != nil {
-		l.lmap[key] = li
+func (l *Listeners) Put(key interface{}, li Listener) Listener {
+	if li == nil {
+		old, _ := l.store.load(key)
+		return old
 	}
-	l.mu.Unlock()
 
-	return
+	return l.store.swap(key, li)
 }
 
 func (l *Listeners) Range(f func(key interface{}, li Listener) bool) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	for key, li := range l.lmap {
+	l.store.rangeAll(f)
+}
+
+// BroadcastAfter broadcasts value after d to the listener of key, created
+// now if missing. Stop the returned timer to cancel.
+func (l *Listeners) BroadcastAfter(key interface{}, d time.Duration, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAfter(li, d, value)
+}
+
+// BroadcastAt is BroadcastAfter with an absolute time.
+func (l *Listeners) BroadcastAt(key interface{}, t time.Time, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAt(li, t, value)
+}
+
+// WaitUntilKey is WaitUntil on the listener of key, created now if
+// missing.
+func (l *Listeners) WaitUntilKey(ctx context.Context, key interface{}, pred func(value interface{}) bool) (interface{}, error) {
+	li, _ := l.GetOrCreate(key)
+	return WaitUntil(ctx, li, pred)
+}
+
+func (l *Listeners) Stats() (s Stats) {
+	l.Range(func(_ interface{}, li Listener) bool {
+		s.add(li)
+		return true
+	})
+
+	return
+}
+
+func (s *anyMapStore) load(key interface{}) (li Listener, found bool) {
+	s.mu.RLock()
+	li, found = s.lmap[key]
+	s.mu.RUnlock()
+
+	return
+}
+
+func (s *anyMapStore) loadOrCreate(key interface{}, c *config) (li Listener, found bool) {
+	s.mu.Lock()
+	li, found = s.lmap[key]
+	if !found {
+		li = c.newListener(key)
+		s.lmap[key] = li
+	}
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *anyMapStore) loadOrStore(key interface{}, li Listener) (old Listener, found bool) {
+	s.mu.Lock()
+	if old, found = s.lmap[key]; !found {
+		s.lmap[key] = li
+		old = li
+	}
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *anyMapStore) swap(key interface{}, li Listener) (old Listener) {
+	s.mu.Lock()
+	old = s.lmap[key]
+	s.lmap[key] = li
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *anyMapStore) loadAndDelete(key interface{}) (li Listener, found bool) {
+	s.mu.Lock()
+	li, found = s.lmap[key]
+	delete(s.lmap, key)
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *anyMapStore) rangeAll(f func(key interface{}, li Listener) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, li := range s.lmap {
 		if !f(key, li) {
 			break
 		}
 	}
 }
+
+func (s *anyMapStore) len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.lmap)
+}
@@ -3,80 +3,182 @@
 package listener
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 type (
-	Listener interface {
+	Broadcaster interface {
 		Broadcast(value interface{})
+	}
+
+	Receiver interface {
 		Receive() (interface{}, bool)
 		Wait() interface{}
 	}
 
+	Listener interface {
+		Broadcaster
+		Receiver
+	}
+
 	Listeners struct {
-		creater func() Listener
-		lmap    sync.Map //map[interface{}]Listener
+		config
+		store anyStore
+	}
+
+	syncMapStore struct {
+		lmap sync.Map //map[interface{}]Listener
+		mu   sync.Mutex // serializes swap and loadAndDelete before go1.20
 	}
 )
 
 func NewListeners(creater ...func() Listener) *Listeners {
-	var c func() Listener = NewListener
-	if len(creater) != 0 && creater[0] != nil {
-		c = creater[0]
-	}
+	return NewListenersWith(firstCreater(creater))
+}
 
-	return &Listeners{
-		creater: c,
+func NewListenersWith(opts ...Option) *Listeners {
+	l := &Listeners{
+		config: newConfig(opts),
+	}
+	if l.backend.anyStore != nil {
+		l.store = l.backend.anyStore(&l.config)
+	} else {
+		l.store = &syncMapStore{}
+	}
+	if l.backend.anyBound != nil {
+		l.store = l.backend.anyBound(l.store, &l.config)
 	}
+
+	return l
 }
 
 func (l *Listeners) GetOrCreate(key interface{}) (Listener, bool) {
-	li, found := l.lmap.Load(key)
+	li, found := l.store.load(key)
 	if !found {
-		li = l.creater()
-		li, found = l.lmap.LoadOrStore(key, li)
+		li, found = l.store.loadOrCreate(key, &l.config)
+		if !found {
+			l.created(key, li)
+			return li, false
+		}
+	}
+	l.metrics.Hit()
+	if l.keyed {
+		l.accessed(key, li)
 	}
 
-	return li.(Listener), found
+	return li, true
 }
 
 func (l *Listeners) Get(key interface{}) (Listener, bool) {
-	if li, found := l.lmap.Load(key); found {
-		return li.(Listener), true
+	return l.store.load(key)
+}
+
+func (l *Listeners) GetReceiver(key interface{}) (Receiver, bool) {
+	if li, found := l.Get(key); found {
+		return ReadOnly(li), true
 	}
 
 	return nil, false
 }
 
-func (l *Listeners) Len() (n int) {
-	l.lmap.Range(func(key, value interface{}) bool {
-		n++
-		return true
-	})
-
-	return
+func (l *Listeners) Len() int {
+	return l.store.len()
 }
 
 func (l *Listeners) Delete(key interface{}) {
-	l.lmap.Delete(key)
+	if li, found := l.store.loadAndDelete(key); found {
+		l.metrics.Deleted()
+		if l.keyed {
+			l.deleted(key, li)
+		}
+		if l.pooled {
+			l.release(li)
+		}
+	}
 }
 
+// Put stores li under key unless the key is taken, like sync.Map's
+// LoadOrStore, and returns the listener that was there.
 func (l *Listeners) Put(key interface{}, li Listener) Listener {
 	if li == nil {
-		old, _ := l.Get(key)
+		old, _ := l.store.load(key)
 		return old
 	}
 
-	old, found := l.lmap.LoadOrStore(key, li)
+	old, found := l.store.loadOrStore(key, li)
 	if !found {
 		return nil
 	}
 
-	return old.(Listener)
+	return old
 }
 
 func (l *Listeners) Range(f func(key interface{}, li Listener) bool) {
-	l.lmap.Range(func(key interface{}, v interface{}) bool {
+	l.store.rangeAll(f)
+}
+
+// BroadcastAfter broadcasts value after d to the listener of key, created
+// now if missing. Stop the returned timer to cancel.
+func (l *Listeners) BroadcastAfter(key interface{}, d time.Duration, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAfter(li, d, value)
+}
+
+// BroadcastAt is BroadcastAfter with an absolute time.
+func (l *Listeners) BroadcastAt(key interface{}, t time.Time, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAt(li, t, value)
+}
+
+// WaitUntilKey is WaitUntil on the listener of key, created now if
+// missing.
+func (l *Listeners) WaitUntilKey(ctx context.Context, key interface{}, pred func(value interface{}) bool) (interface{}, error) {
+	li, _ := l.GetOrCreate(key)
+	return WaitUntil(ctx, li, pred)
+}
+
+func (l *Listeners) Stats() (s Stats) {
+	l.Range(func(_ interface{}, li Listener) bool {
+		s.add(li)
+		return true
+	})
+
+	return
+}
+
+func (s *syncMapStore) load(key interface{}) (Listener, bool) {
+	if li, found := s.lmap.Load(key); found {
+		return li.(Listener), true
+	}
+
+	return nil, false
+}
+
+func (s *syncMapStore) loadOrCreate(key interface{}, c *config) (Listener, bool) {
+	li, found := s.lmap.LoadOrStore(key, c.newListener(key))
+
+	return li.(Listener), found
+}
+
+func (s *syncMapStore) loadOrStore(key interface{}, li Listener) (Listener, bool) {
+	old, found := s.lmap.LoadOrStore(key, li)
+
+	return old.(Listener), found
+}
+
+func (s *syncMapStore) rangeAll(f func(key interface{}, li Listener) bool) {
+	s.lmap.Range(func(key interface{}, v interface{}) bool {
 		return f(key, v.(Listener))
 	})
 }
+
+func (s *syncMapStore) len() (n int) {
+	s.lmap.Range(func(key, value interface{}) bool {
+		n++
+		return true
+	})
+
+	return
+}
@@ -0,0 +1,101 @@
+// Package listenertest provides helpers for testing code built on listener.
+package listenertest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jenchik/listener"
+)
+
+type (
+	// Clock is a manually advanced listener.Clock. Callbacks scheduled
+	// with AfterFunc run from Advance, in deadline order.
+	Clock struct {
+		mu     sync.Mutex
+		now    time.Time
+		timers []*timer
+	}
+
+	timer struct {
+		clock *Clock
+		at    time.Time
+		f     func()
+	}
+)
+
+var (
+	_ listener.TimerClock = &Clock{}
+	_ listener.Timer      = &timer{}
+)
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now: now,
+	}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) AfterFunc(d time.Duration, f func()) listener.Timer {
+	c.mu.Lock()
+	t := &timer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+
+	return t
+}
+
+// Advance moves the clock forward, running every callback that falls
+// due on the way with the clock set to its deadline.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		sort.SliceStable(c.timers, func(i, j int) bool {
+			return c.timers[i].at.Before(c.timers[j].at)
+		})
+		if len(c.timers) == 0 || c.timers[0].at.After(end) {
+			break
+		}
+
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+	c.now = end
+	c.mu.Unlock()
+}
+
+// Pending returns the number of scheduled callbacks.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package listener
+
+import (
+	"time"
+)
+
+type (
+	Metrics interface {
+		Created()
+		Hit()
+		Miss()
+		Deleted()
+		Broadcast()
+		WaitStarted()
+		WaitDone(d time.Duration)
+	}
+
+	NopMetrics struct{}
+)
+
+var (
+	_ Metrics = NopMetrics{}
+)
+
+func (NopMetrics) Created() {}
+
+func (NopMetrics) Hit() {}
+
+func (NopMetrics) Miss() {}
+
+func (NopMetrics) Deleted() {}
+
+func (NopMetrics) Broadcast() {}
+
+func (NopMetrics) WaitStarted() {}
+
+func (NopMetrics) WaitDone(time.Duration) {}
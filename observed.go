@@ -0,0 +1,92 @@
+package listener
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// observed wraps listeners created by a registry that has metrics or
+	// hooks configured, so that custom listener types are covered as well.
+	observed struct {
+		wrapper
+		key interface{}
+		cfg *config
+	}
+)
+
+var (
+	_ Listener  = &observed{}
+	_ Inspector = &observed{}
+	_ Closer    = &observed{}
+
+	_ TryBroadcaster = &observed{}
+)
+
+func (o *observed) Broadcast(value interface{}) {
+	o.TryBroadcast(value)
+}
+
+func (o *observed) TryBroadcast(value interface{}) error {
+	return o.broadcast(value, 0, false)
+}
+
+func (o *observed) BroadcastTTL(value interface{}, ttl time.Duration) {
+	o.broadcast(value, ttl, true)
+}
+
+func (o *observed) broadcast(value interface{}, ttl time.Duration, expiring bool) (err error) {
+	for _, ic := range o.cfg.interceptors {
+		if value, err = ic.OnBroadcast(o.key, value); err != nil {
+			return
+		}
+	}
+	var dones []func()
+	for _, t := range o.cfg.tracers {
+		if done := t.broadcast(o.cfg.name, o.key, o.Listener, value); done != nil {
+			dones = append(dones, done)
+		}
+	}
+
+	o.cfg.metrics.Broadcast()
+	if expiring {
+		BroadcastTTL(o.Listener, value, ttl)
+	} else {
+		o.Listener.Broadcast(value)
+	}
+
+	for i := len(dones) - 1; i >= 0; i-- {
+		dones[i]()
+	}
+
+	return
+}
+
+func (o *observed) Wait() interface{} {
+	var wakes []func(interface{})
+	for _, t := range o.cfg.tracers {
+		if wake := t.wait(context.Background(), o.cfg.name, o.key, o.Listener); wake != nil {
+			wakes = append(wakes, wake)
+		}
+	}
+
+	o.cfg.metrics.WaitStarted()
+	start := time.Now()
+	value := o.Listener.Wait()
+	o.cfg.metrics.WaitDone(time.Since(start))
+
+	for i := len(wakes) - 1; i >= 0; i-- {
+		wakes[i](value)
+	}
+	for _, ic := range o.cfg.interceptors {
+		ic.OnWake(o.key, value)
+	}
+
+	return value
+}
+
+func (o *observed) Close(err error) {
+	if c, ok := o.Listener.(Closer); ok {
+		c.Close(err)
+	}
+}
@@ -0,0 +1,105 @@
+package listener
+
+type (
+	Option func(*config)
+
+	config struct {
+		name         string
+		creater      func() Listener
+		metrics      Metrics
+		interceptors []Interceptor
+		tracers      []tracer
+		backend      backend
+		wheel        *TimerWheel
+		keyed        bool
+		pooled       bool
+		observe      bool
+	}
+)
+
+// WithName names the registry in logs, profiles and traces.
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+func WithCreater(creater func() Listener) Option {
+	return func(c *config) {
+		if creater != nil {
+			c.creater = creater
+		}
+	}
+}
+
+func WithMetrics(m Metrics) Option {
+	return func(c *config) {
+		if m != nil {
+			c.metrics = m
+		}
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		creater: NewListener,
+		metrics: NopMetrics{},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.pooled {
+		c.creater = poolCreater()
+	}
+	_, nop := c.metrics.(NopMetrics)
+	c.keyed = len(c.interceptors) != 0 || len(c.tracers) != 0
+	c.observe = !nop || c.keyed
+
+	return c
+}
+
+// Observe wraps a standalone listener so that the metrics, interceptors and
+// tracing given by opts apply to it as if it was created by a registry
+// under key.
+func Observe(li Listener, key interface{}, opts ...Option) Listener {
+	c := newConfig(opts)
+
+	return &observed{wrapper: wrapper{li}, key: key, cfg: &c}
+}
+
+func firstCreater(creater []func() Listener) Option {
+	if len(creater) == 0 {
+		return WithCreater(nil)
+	}
+
+	return WithCreater(creater[0])
+}
+
+func (c *config) newListener(key interface{}) Listener {
+	li := c.creater()
+	if c.observe {
+		li = &observed{wrapper: wrapper{li}, key: key, cfg: c}
+	}
+
+	return li
+}
+
+func (c *config) created(key interface{}, li Listener) {
+	c.metrics.Miss()
+	c.metrics.Created()
+	for _, ic := range c.interceptors {
+		ic.OnCreate(key, li)
+	}
+	for _, t := range c.tracers {
+		t.access(c.name, key, li, false)
+	}
+}
+
+func (c *config) deleted(key interface{}, li Listener) {
+	for _, ic := range c.interceptors {
+		ic.OnDelete(key, li)
+	}
+	for _, t := range c.tracers {
+		t.delete(c.name, key, li)
+	}
+}
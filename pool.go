@@ -0,0 +1,264 @@
+package listener
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// pooledCore is the recyclable part of a pooled listener. It is only
+	// ever reached through a pooled handle whose gen matches.
+	pooledCore struct {
+		listener
+		gen     uint64
+		deleted bool
+	}
+
+	// pooled is the handle returned by registries using WithOncePool. When
+	// its core is recycled the handle keeps a snapshot of the final state,
+	// so stale holders never see what the next owner does with the core.
+	pooled struct {
+		core    *pooledCore
+		gen     uint64
+		pool    *sync.Pool
+		value   interface{}
+		state   State
+		count   uint64
+		created int64
+		fired   int64
+	}
+)
+
+var (
+	_ Listener  = &pooled{}
+	_ Inspector = &pooled{}
+	_ Closer    = &pooled{}
+
+	_ UntilWaiter = &pooled{}
+)
+
+// WithOncePool makes the registry create listeners with NewListenerOnce
+// semantics from a pool, and recycle them after Delete once they have
+// fired and nobody waits on them. Holders of a recycled listener keep
+// seeing its final value. It replaces the creater, so a creater given
+// with WithCreater or to the constructor is ignored, whatever the order
+// of the options. Resend listeners are not pooled: one costs no more to
+// allocate than the handle that guards its recycling.
+func WithOncePool() Option {
+	return func(c *config) {
+		c.pooled = true
+	}
+}
+
+func poolCreater() func() Listener {
+	p := &sync.Pool{}
+	p.New = func() interface{} {
+		return &pooledCore{}
+	}
+
+	return func() Listener {
+		core := p.Get().(*pooledCore)
+		core.created = time.Now().UnixNano()
+		return &pooled{
+			core: core,
+			gen:  core.gen,
+			pool: p,
+		}
+	}
+}
+
+// release is called by registries after li has been deleted.
+func (c *config) release(li Listener) {
+	if p, ok := unwrap(li).(*pooled); ok {
+		p.release()
+	}
+}
+
+func (p *pooled) release() {
+	c := p.core
+	c.mu.Lock()
+	if c.gen == p.gen {
+		c.deleted = true
+	}
+	p.recycle()
+}
+
+// recycle must be called with p.core.mu held; it unlocks it.
+func (p *pooled) recycle() {
+	c := p.core
+	if c.gen != p.gen || !c.deleted || c.trigger == 0 || c.waiters != 0 {
+		c.mu.Unlock()
+		return
+	}
+
+	p.value = c.value
+	p.state = c.State()
+	p.count = atomic.LoadUint64(&c.count)
+	p.created = c.created
+	p.fired = c.fired
+
+	c.gen++
+	c.count = 0
+	c.fired = 0
+	c.value = nil
+	c.trigger = 0
+	c.closed = 0
+	c.deleted = false
+	c.mu.Unlock()
+
+	p.pool.Put(c)
+}
+
+func (p *pooled) Broadcast(value interface{}) {
+	c := p.core
+	c.mu.Lock()
+	if c.gen != p.gen {
+		c.mu.Unlock()
+		return
+	}
+
+	atomic.AddUint64(&c.count, 1)
+	if c.closed == 0 && c.trigger == 0 {
+		c.fire(value, 0)
+	}
+	p.recycle()
+}
+
+func (p *pooled) Receive() (interface{}, bool) {
+	c := p.core
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gen != p.gen {
+		return p.value, true
+	}
+	if c.trigger == 0 {
+		return nil, false
+	}
+
+	return c.value, true
+}
+
+func (p *pooled) Wait() interface{} {
+	value, _, _ := p.wait(nil)
+	return value
+}
+
+func (p *pooled) WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error) {
+	value, closed, ok := p.wait(ctx.Done())
+	if !ok {
+		return nil, ctx.Err()
+	}
+
+	return onceUntil(pred, value, closed)
+}
+
+// wait blocks until the listener fires or cancel is closed, in which
+// case ok is false.
+func (p *pooled) wait(cancel <-chan struct{}) (value interface{}, closed, ok bool) {
+	c := p.core
+	c.mu.Lock()
+	if c.gen != p.gen {
+		c.mu.Unlock()
+		return p.value, p.state == StateClosed, true
+	}
+	if c.trigger != 0 {
+		value, closed = c.value, c.closed != 0
+		c.mu.Unlock()
+		return value, closed, true
+	}
+
+	atomic.AddInt32(&c.waiters, 1)
+	if c.wake == nil {
+		c.wake = &wakeup{done: make(chan struct{})}
+	}
+	w := c.wake
+	c.mu.Unlock()
+
+	select {
+	case <-w.done:
+		value, ok = w.value, true
+	case <-cancel:
+	}
+
+	c.mu.Lock()
+	atomic.AddInt32(&c.waiters, -1)
+	closed = c.closed != 0
+	p.recycle()
+
+	return
+}
+
+func (p *pooled) Close(err error) {
+	c := p.core
+	c.mu.Lock()
+	if c.gen != p.gen || c.closed != 0 {
+		c.mu.Unlock()
+		return
+	}
+
+	atomic.StoreUint32(&c.closed, 1)
+	if c.trigger == 0 {
+		if err == nil {
+			err = ErrClosed
+		}
+		c.fire(err, 0)
+	}
+	p.recycle()
+}
+
+func (p *pooled) State() State {
+	c := p.core
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gen != p.gen {
+		return p.state
+	}
+
+	return c.State()
+}
+
+func (p *pooled) Waiters() int {
+	c := p.core
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gen != p.gen {
+		return 0
+	}
+
+	return c.Waiters()
+}
+
+func (p *pooled) CreatedAt() time.Time {
+	c := p.core
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gen != p.gen {
+		return unixTime(p.created)
+	}
+
+	return c.CreatedAt()
+}
+
+func (p *pooled) FiredAt() time.Time {
+	c := p.core
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gen != p.gen {
+		return unixTime(p.fired)
+	}
+
+	return c.FiredAt()
+}
+
+func (p *pooled) BroadcastCount() uint64 {
+	c := p.core
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gen != p.gen {
+		return p.count
+	}
+
+	return c.BroadcastCount()
+}
@@ -0,0 +1,77 @@
+//go:build go1.11
+// +build go1.11
+
+package listener
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+type (
+	profTracer struct{}
+)
+
+var (
+	_ tracer = profTracer{}
+)
+
+// WithProfiling labels goroutines parked in Wait with the registry name and
+// key (pprof labels "listener.registry" and "listener.key"), so that they
+// can be told apart in goroutine dumps and profiles. While runtime tracing
+// is enabled Wait and Broadcast are also recorded as trace tasks and
+// regions. WaitUntil adds the labels to those of its context, such as the
+// ones set by pprof.Do, and puts the context's labels back once it wakes.
+// Wait has no context: it replaces the goroutine's labels while parked and
+// clears them when it wakes, use WaitUntil to keep the caller's labels.
+func WithProfiling() Option {
+	return withTracer(profTracer{})
+}
+
+func (profTracer) access(string, interface{}, Listener, bool) {}
+
+func (profTracer) delete(string, interface{}, Listener) {}
+
+func (profTracer) broadcast(name string, key interface{}, li Listener, value interface{}) func() {
+	if !trace.IsEnabled() {
+		return nil
+	}
+
+	ctx, task := trace.NewTask(context.Background(), "listener.Broadcast")
+	trace.Log(ctx, "listener.registry", name)
+	trace.Log(ctx, "listener.key", fmt.Sprint(key))
+	region := trace.StartRegion(ctx, "listener.Broadcast")
+
+	return func() {
+		region.End()
+		task.End()
+	}
+}
+
+func (profTracer) wait(caller context.Context, name string, key interface{}, li Listener) func(value interface{}) {
+	k := fmt.Sprint(key)
+	ctx := pprof.WithLabels(caller, pprof.Labels(
+		"listener.registry", name,
+		"listener.key", k,
+	))
+	pprof.SetGoroutineLabels(ctx)
+
+	var task *trace.Task
+	var region *trace.Region
+	if trace.IsEnabled() {
+		ctx, task = trace.NewTask(ctx, "listener.Wait")
+		trace.Log(ctx, "listener.registry", name)
+		trace.Log(ctx, "listener.key", k)
+		region = trace.StartRegion(ctx, "listener.Wait")
+	}
+
+	return func(interface{}) {
+		if task != nil {
+			region.End()
+			task.End()
+		}
+		pprof.SetGoroutineLabels(caller)
+	}
+}
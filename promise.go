@@ -0,0 +1,109 @@
+package listener
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+type (
+	// Resolver is the producer side of a promise. A resolver that is
+	// released or garbage collected before Resolve is called abandons the
+	// promise: waiters wake with ErrAbandoned and the event is reported.
+	Resolver interface {
+		Resolve(value interface{})
+		Release()
+	}
+
+	Abandoned struct {
+		// Collected is set when the resolver was garbage collected
+		// rather than released.
+		Collected bool
+		// Stack where the promise was created, if PromiseOptions.Stack
+		// was set.
+		Stack []byte
+	}
+
+	PromiseOptions struct {
+		// OnAbandoned overrides the handler set by SetAbandonedHandler.
+		OnAbandoned func(Abandoned)
+		Stack       bool
+	}
+
+	resolver struct {
+		li          *listenerOnce
+		done        uint32
+		onAbandoned func(Abandoned)
+		stack       []byte
+	}
+)
+
+var (
+	ErrAbandoned = errors.New("listener: promise abandoned")
+
+	abandonedHandler atomic.Value // func(Abandoned)
+
+	_ Resolver = &resolver{}
+)
+
+// SetAbandonedHandler sets the default handler of abandoned promises.
+func SetAbandonedHandler(f func(Abandoned)) {
+	abandonedHandler.Store(f)
+}
+
+// NewPromise returns a resolver and the read-only listener it resolves.
+func NewPromise(opts ...PromiseOptions) (Resolver, Receiver) {
+	var o PromiseOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+
+	r := &resolver{
+		li:          newListenerOnce(),
+		onAbandoned: o.OnAbandoned,
+	}
+	if o.Stack {
+		r.stack = stack()
+	}
+	runtime.SetFinalizer(r, func(r *resolver) {
+		r.abandon(true)
+	})
+
+	return r, ReadOnly(r.li)
+}
+
+func (r *resolver) Resolve(value interface{}) {
+	if atomic.CompareAndSwapUint32(&r.done, 0, 1) {
+		runtime.SetFinalizer(r, nil)
+		r.li.Broadcast(value)
+	}
+}
+
+func (r *resolver) Release() {
+	if atomic.LoadUint32(&r.done) == 0 {
+		runtime.SetFinalizer(r, nil)
+		r.abandon(false)
+	}
+}
+
+func (r *resolver) abandon(collected bool) {
+	if !atomic.CompareAndSwapUint32(&r.done, 0, 1) {
+		return
+	}
+	if atomic.LoadUint32(&r.li.trigger) != 0 {
+		// resolved by other means, nothing was abandoned
+		return
+	}
+	r.li.Close(ErrAbandoned)
+
+	f := r.onAbandoned
+	if f == nil {
+		f, _ = abandonedHandler.Load().(func(Abandoned))
+	}
+	if f != nil {
+		f(Abandoned{
+			Collected: collected,
+			Stack:     r.stack,
+		})
+	}
+}
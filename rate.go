@@ -0,0 +1,188 @@
+package listener
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Edge selects which broadcasts of a burst or interval pass through.
+	Edge uint8
+
+	RateOptions struct {
+		Clock Clock
+		// Edges defaults to EdgeTrailing for Debounce and to both edges
+		// for Throttle; Sample ignores it.
+		Edges Edge
+	}
+
+	rateMode uint8
+
+	// rateLimited passes broadcasts on to the wrapped listener at a
+	// limited rate; receivers only see what gets through.
+	rateLimited struct {
+		wrapper
+		mode    rateMode
+		d       time.Duration
+		clock   Clock
+		edges   Edge
+		mu      sync.Mutex
+		timer   Timer // nil while idle
+		gen     uint64
+		pending interface{}
+		has     bool
+		closed  bool
+	}
+)
+
+const (
+	EdgeLeading Edge = 1 << iota
+	EdgeTrailing
+)
+
+const (
+	debounce rateMode = iota
+	throttle
+	sample
+)
+
+var (
+	_ Listener  = &rateLimited{}
+	_ Inspector = &rateLimited{}
+	_ Closer    = &rateLimited{}
+)
+
+// NewDebounced passes a broadcast on to li once no other one followed it
+// for quiet; with EdgeLeading the first broadcast of a burst passes at
+// once.
+func NewDebounced(li Listener, quiet time.Duration, opts ...RateOptions) Listener {
+	return newRateLimited(li, debounce, quiet, EdgeTrailing, opts)
+}
+
+// NewThrottled passes at most one broadcast per interval on to li: the
+// first one of the interval (EdgeLeading) and/or the last one at its end
+// (EdgeTrailing).
+func NewThrottled(li Listener, interval time.Duration, opts ...RateOptions) Listener {
+	return newRateLimited(li, throttle, interval, EdgeLeading|EdgeTrailing, opts)
+}
+
+// NewSampled passes the latest broadcast on to li every interval, if
+// there was a new one.
+func NewSampled(li Listener, interval time.Duration, opts ...RateOptions) Listener {
+	return newRateLimited(li, sample, interval, EdgeTrailing, opts)
+}
+
+// DebounceCreater wraps the listeners made by creater with NewDebounced.
+func DebounceCreater(creater func() Listener, quiet time.Duration, opts ...RateOptions) func() Listener {
+	return func() Listener {
+		return NewDebounced(creater(), quiet, opts...)
+	}
+}
+
+// ThrottleCreater wraps the listeners made by creater with NewThrottled.
+func ThrottleCreater(creater func() Listener, interval time.Duration, opts ...RateOptions) func() Listener {
+	return func() Listener {
+		return NewThrottled(creater(), interval, opts...)
+	}
+}
+
+// SampleCreater wraps the listeners made by creater with NewSampled.
+func SampleCreater(creater func() Listener, interval time.Duration, opts ...RateOptions) func() Listener {
+	return func() Listener {
+		return NewSampled(creater(), interval, opts...)
+	}
+}
+
+func newRateLimited(li Listener, mode rateMode, d time.Duration, edges Edge, opts []RateOptions) *rateLimited {
+	var o RateOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+	if o.Clock == nil {
+		o.Clock = SystemClock
+	}
+	if o.Edges != 0 && mode != sample {
+		edges = o.Edges
+	}
+
+	return &rateLimited{
+		wrapper: wrapper{li},
+		mode:    mode,
+		d:       d,
+		clock:   o.Clock,
+		edges:   edges,
+	}
+}
+
+func (r *rateLimited) Broadcast(value interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	idle := r.timer == nil
+	if idle && r.edges&EdgeLeading != 0 {
+		r.Listener.Broadcast(value)
+	} else {
+		r.pending, r.has = value, true
+	}
+
+	switch {
+	case r.mode == debounce:
+		if !idle {
+			r.timer.Stop()
+		}
+		r.start()
+	case idle:
+		r.start()
+	}
+}
+
+// start must be called with r.mu held.
+func (r *rateLimited) start() {
+	r.gen++
+	gen := r.gen
+	r.timer = afterFunc(r.clock, r.d, func() {
+		r.tick(gen)
+	})
+}
+
+func (r *rateLimited) tick(gen uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gen != r.gen {
+		return
+	}
+
+	r.timer = nil
+	if r.has && r.edges&EdgeTrailing != 0 {
+		r.Listener.Broadcast(r.pending)
+		if r.mode != debounce {
+			// the value opens the next interval
+			r.start()
+		}
+	}
+	r.pending, r.has = nil, false
+}
+
+// Close passes on a value still held for the trailing edge and then
+// closes the wrapped listener. Later broadcasts are dropped.
+func (r *rateLimited) Close(err error) {
+	r.mu.Lock()
+	r.closed = true
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.gen++
+	if r.has && r.edges&EdgeTrailing != 0 {
+		r.Listener.Broadcast(r.pending)
+	}
+	r.pending, r.has = nil, false
+	r.mu.Unlock()
+
+	if c, ok := r.Listener.(Closer); ok {
+		c.Close(err)
+	}
+}
@@ -0,0 +1,51 @@
+package listener
+
+import (
+	"time"
+)
+
+type (
+	// receiver hides the Broadcaster side of a listener. It cannot embed
+	// the wrapper, that would promote Broadcast.
+	receiver struct {
+		li wrapper
+	}
+)
+
+var (
+	_ Receiver  = &receiver{}
+	_ Inspector = &receiver{}
+)
+
+// ReadOnly returns a view of li that can only receive values.
+func ReadOnly(li Listener) Receiver {
+	return &receiver{li: wrapper{li}}
+}
+
+func (r *receiver) Receive() (interface{}, bool) {
+	return r.li.Receive()
+}
+
+func (r *receiver) Wait() interface{} {
+	return r.li.Wait()
+}
+
+func (r *receiver) State() State {
+	return r.li.State()
+}
+
+func (r *receiver) Waiters() int {
+	return r.li.Waiters()
+}
+
+func (r *receiver) CreatedAt() time.Time {
+	return r.li.CreatedAt()
+}
+
+func (r *receiver) FiredAt() time.Time {
+	return r.li.FiredAt()
+}
+
+func (r *receiver) BroadcastCount() uint64 {
+	return r.li.BroadcastCount()
+}
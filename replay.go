@@ -0,0 +1,297 @@
+package listener
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	ReplayOptions struct {
+		// Size keeps at most the last Size values.
+		Size int
+		// Window keeps only values broadcast within the last Window.
+		// Without Size and Window the last 16 values are kept.
+		Window time.Duration
+		Clock  Clock
+	}
+
+	// Replayer is implemented by listeners that keep a history.
+	Replayer interface {
+		Subscribe() Subscription
+	}
+
+	// Subscription reads a replay listener's history and then follows its
+	// broadcasts. A subscriber that falls behind the retained history
+	// skips to its oldest value.
+	Subscription interface {
+		// Next returns the next value, blocking until one is broadcast.
+		// After the listener is closed and the history drained it
+		// returns the close error.
+		Next(ctx context.Context) (interface{}, error)
+		Close()
+	}
+
+	replayListener struct {
+		mu      sync.Mutex
+		opts    ReplayOptions
+		hist    []replayEntry
+		first   uint64 // seq of hist[0]
+		next    uint64 // seq of the next broadcast
+		value   interface{}
+		count   uint64
+		created int64
+		fired   int64
+		waiters int32
+		err     error         // set by Close
+		changed chan struct{} // closed on every broadcast and on Close
+	}
+
+	replayEntry struct {
+		at    int64
+		value interface{}
+	}
+
+	subscription struct {
+		l      *replayListener
+		cursor uint64
+		done   chan struct{}
+		once   sync.Once
+	}
+)
+
+var (
+	_ Listener  = &replayListener{}
+	_ Inspector = &replayListener{}
+	_ Closer    = &replayListener{}
+	_ Replayer  = &replayListener{}
+
+	_ Subscription = &subscription{}
+)
+
+// NewReplayListener returns a resend listener that also keeps a bounded
+// history of its broadcasts for subscribers.
+func NewReplayListener(opts ...ReplayOptions) Listener {
+	var o ReplayOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+	if o.Size <= 0 && o.Window <= 0 {
+		o.Size = 16
+	}
+	if o.Clock == nil {
+		o.Clock = SystemClock
+	}
+
+	return &replayListener{
+		opts:    o,
+		created: o.Clock.Now().UnixNano(),
+	}
+}
+
+// ReplayCreater returns a creater for registries that makes replay
+// listeners.
+func ReplayCreater(opts ...ReplayOptions) func() Listener {
+	return func() Listener {
+		return NewReplayListener(opts...)
+	}
+}
+
+// Subscribe subscribes to li if it keeps a history.
+func Subscribe(li Listener) (Subscription, bool) {
+	if r, ok := unwrap(li).(Replayer); ok {
+		return r.Subscribe(), true
+	}
+
+	return nil, false
+}
+
+func (l *replayListener) Broadcast(value interface{}) {
+	atomic.AddUint64(&l.count, 1)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+
+	now := l.opts.Clock.Now().UnixNano()
+	l.value = value
+	atomic.StoreInt64(&l.fired, now)
+	l.hist = append(l.hist, replayEntry{at: now, value: value})
+	l.next++
+	l.trim(now)
+	l.notify()
+}
+
+// trim must be called with l.mu held.
+func (l *replayListener) trim(now int64) {
+	n := 0
+	if l.opts.Size > 0 && len(l.hist) > l.opts.Size {
+		n = len(l.hist) - l.opts.Size
+	}
+	if l.opts.Window > 0 {
+		for n < len(l.hist) && now-l.hist[n].at > int64(l.opts.Window) {
+			n++
+		}
+	}
+	if n != 0 {
+		for i := 0; i < n; i++ {
+			l.hist[i] = replayEntry{}
+		}
+		l.hist = l.hist[n:]
+		l.first += uint64(n)
+	}
+}
+
+// notify must be called with l.mu held.
+func (l *replayListener) notify() {
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+}
+
+// wait must be called with l.mu held.
+func (l *replayListener) wait() <-chan struct{} {
+	if l.changed == nil {
+		l.changed = make(chan struct{})
+	}
+
+	return l.changed
+}
+
+func (l *replayListener) Receive() (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.value, l.next != 0 || l.err != nil
+}
+
+func (l *replayListener) Wait() interface{} {
+	l.mu.Lock()
+	if l.next == 0 && l.err == nil {
+		atomic.AddInt32(&l.waiters, 1)
+		for l.next == 0 && l.err == nil {
+			ch := l.wait()
+			l.mu.Unlock()
+			<-ch
+			l.mu.Lock()
+		}
+		atomic.AddInt32(&l.waiters, -1)
+	}
+	value := l.value
+	l.mu.Unlock()
+
+	return value
+}
+
+// Close wakes waiters and ends subscriptions with err (ErrClosed if nil)
+// and drops all further broadcasts. A value that has already fired is
+// kept, as is the history.
+func (l *replayListener) Close(err error) {
+	if err == nil {
+		err = ErrClosed
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	l.err = err
+	if l.next == 0 {
+		l.value = err
+		atomic.StoreInt64(&l.fired, l.opts.Clock.Now().UnixNano())
+	}
+	l.notify()
+}
+
+func (l *replayListener) Subscribe() Subscription {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return &subscription{
+		l:      l,
+		cursor: l.first,
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *subscription) Next(ctx context.Context) (interface{}, error) {
+	l := s.l
+	for {
+		select {
+		case <-s.done:
+			return nil, ErrClosed
+		default:
+		}
+
+		l.mu.Lock()
+		l.trim(l.opts.Clock.Now().UnixNano())
+		if s.cursor < l.first {
+			s.cursor = l.first
+		}
+		if s.cursor < l.next {
+			value := l.hist[s.cursor-l.first].value
+			s.cursor++
+			l.mu.Unlock()
+			return value, nil
+		}
+		if l.err != nil {
+			err := l.err
+			l.mu.Unlock()
+			return nil, err
+		}
+		ch := l.wait()
+		atomic.AddInt32(&l.waiters, 1)
+		l.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+		case <-s.done:
+		}
+		atomic.AddInt32(&l.waiters, -1)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close ends the subscription; a blocked Next returns ErrClosed.
+func (s *subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (l *replayListener) State() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case l.err != nil:
+		return StateClosed
+	case l.next != 0:
+		return StateFired
+	}
+
+	return StatePending
+}
+
+func (l *replayListener) Waiters() int {
+	return int(atomic.LoadInt32(&l.waiters))
+}
+
+func (l *replayListener) CreatedAt() time.Time {
+	return unixTime(l.created)
+}
+
+func (l *replayListener) FiredAt() time.Time {
+	return unixTime(atomic.LoadInt64(&l.fired))
+}
+
+func (l *replayListener) BroadcastCount() uint64 {
+	return atomic.LoadUint64(&l.count)
+}
@@ -0,0 +1,184 @@
+//go:build go1.24
+// +build go1.24
+
+package listener
+
+import (
+	"hash/maphash"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	shard[K comparable] struct {
+		mu   sync.RWMutex
+		lmap map[K]Listener
+		_    [32]byte // pad to a cache line
+	}
+
+	shardedStore[K comparable] struct {
+		shards []shard[K]
+		mask   uint64
+		hash   func(K) uint64
+		count  int64
+	}
+)
+
+var (
+	_ anyStore    = &shardedStore[interface{}]{}
+	_ intStore    = &shardedStore[int]{}
+	_ stringStore = &shardedStore[string]{}
+)
+
+// WithShards selects a registry backend that splits keys over n
+// independently locked maps (n is rounded up to a power of two; n <= 0
+// uses 8 per CPU). Unlike the default backends it does not box int and
+// string keys and keeps create/delete churn on different keys from
+// contending on a single lock.
+func WithShards(n int) Option {
+	n = shardCount(n)
+	seed := maphash.MakeSeed()
+	return func(c *config) {
+		c.backend.anyStore = func(*config) anyStore {
+			return newShardedStore(n, func(key interface{}) uint64 {
+				return maphash.Comparable(seed, key)
+			})
+		}
+		c.backend.intStore = func(*config) intStore {
+			return newShardedStore(n, hashInt)
+		}
+		c.backend.stringStore = func(*config) stringStore {
+			return newShardedStore(n, func(key string) uint64 {
+				return maphash.String(seed, key)
+			})
+		}
+	}
+}
+
+func shardCount(n int) int {
+	if n <= 0 {
+		n = 8 * runtime.GOMAXPROCS(0)
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+
+	return size
+}
+
+func hashInt(key int) uint64 {
+	// splitmix64 finalizer: sequential ids spread over all shards.
+	h := uint64(key)
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+
+	return h
+}
+
+func newShardedStore[K comparable](n int, hash func(K) uint64) *shardedStore[K] {
+	s := &shardedStore[K]{
+		shards: make([]shard[K], n),
+		mask:   uint64(n - 1),
+		hash:   hash,
+	}
+	for i := range s.shards {
+		s.shards[i].lmap = make(map[K]Listener)
+	}
+
+	return s
+}
+
+func (s *shardedStore[K]) shard(key K) *shard[K] {
+	return &s.shards[s.hash(key)&s.mask]
+}
+
+func (s *shardedStore[K]) load(key K) (li Listener, found bool) {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	li, found = sh.lmap[key]
+	sh.mu.RUnlock()
+
+	return
+}
+
+func (s *shardedStore[K]) loadOrCreate(key K, c *config) (li Listener, found bool) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	li, found = sh.lmap[key]
+	if !found {
+		li = c.newListener(key)
+		sh.lmap[key] = li
+		atomic.AddInt64(&s.count, 1)
+	}
+	sh.mu.Unlock()
+
+	return
+}
+
+func (s *shardedStore[K]) loadOrStore(key K, li Listener) (old Listener, found bool) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	if old, found = sh.lmap[key]; !found {
+		sh.lmap[key] = li
+		old = li
+		atomic.AddInt64(&s.count, 1)
+	}
+	sh.mu.Unlock()
+
+	return
+}
+
+func (s *shardedStore[K]) swap(key K, li Listener) Listener {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	old, found := sh.lmap[key]
+	sh.lmap[key] = li
+	if !found {
+		atomic.AddInt64(&s.count, 1)
+	}
+	sh.mu.Unlock()
+
+	return old
+}
+
+func (s *shardedStore[K]) loadAndDelete(key K) (li Listener, found bool) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	li, found = sh.lmap[key]
+	if found {
+		delete(sh.lmap, key)
+		atomic.AddInt64(&s.count, -1)
+	}
+	sh.mu.Unlock()
+
+	return
+}
+
+func (s *shardedStore[K]) rangeAll(f func(key K, li Listener) bool) {
+	for i := range s.shards {
+		if !s.shards[i].rangeAll(f) {
+			return
+		}
+	}
+}
+
+func (sh *shard[K]) rangeAll(f func(key K, li Listener) bool) bool {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	for key, li := range sh.lmap {
+		if !f(key, li) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *shardedStore[K]) len() int {
+	return int(atomic.LoadInt64(&s.count))
+}
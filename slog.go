@@ -0,0 +1,176 @@
+//go:build go1.21
+// +build go1.21
+
+package listener
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	LogOptions struct {
+		// Level of the lifecycle events, slog.LevelDebug by default. A
+		// *slog.LevelVar allows tracing to be switched on at runtime.
+		Level slog.Leveler
+		// Wakes that took at least SlowWait are logged at SlowLevel
+		// (slog.LevelWarn by default) regardless of Level and sampling.
+		SlowWait  time.Duration
+		SlowLevel slog.Leveler
+		// After Burst events on a key only every Sample-th one is logged.
+		// Zero Sample logs every event.
+		Sample int
+		Burst  int
+	}
+
+	slogTracer struct {
+		logger    *slog.Logger
+		level     slog.Leveler
+		slow      time.Duration
+		slowLevel slog.Leveler
+		sample    uint64
+		burst     uint64
+		counts    sync.Map // key -> *uint64
+	}
+)
+
+var (
+	_ tracer = &slogTracer{}
+)
+
+func WithLogger(logger *slog.Logger, opts ...LogOptions) Option {
+	if logger == nil {
+		return func(*config) {}
+	}
+
+	return withTracer(newSlogTracer(logger, opts))
+}
+
+// NewLoggedListener wraps li so that its broadcasts and waits are logged
+// under the given name.
+func NewLoggedListener(li Listener, name interface{}, logger *slog.Logger, opts ...LogOptions) Listener {
+	return Observe(li, name, WithLogger(logger, opts...))
+}
+
+func newSlogTracer(logger *slog.Logger, opts []LogOptions) *slogTracer {
+	var o LogOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	if o.SlowLevel == nil {
+		o.SlowLevel = slog.LevelWarn
+	}
+	if o.Sample < 0 {
+		o.Sample = 0
+	}
+	if o.Burst < 0 {
+		o.Burst = 0
+	}
+
+	return &slogTracer{
+		logger:    logger,
+		level:     o.Level,
+		slow:      o.SlowWait,
+		slowLevel: o.SlowLevel,
+		sample:    uint64(o.Sample),
+		burst:     uint64(o.Burst),
+	}
+}
+
+func (t *slogTracer) enabled(level slog.Leveler) bool {
+	return t.logger.Enabled(context.Background(), level.Level())
+}
+
+func (t *slogTracer) sampled(key interface{}) bool {
+	if t.sample <= 1 {
+		return true
+	}
+
+	v, found := t.counts.Load(key)
+	if !found {
+		v, _ = t.counts.LoadOrStore(key, new(uint64))
+	}
+	n := atomic.AddUint64(v.(*uint64), 1)
+
+	return n <= t.burst || (n-t.burst)%t.sample == 0
+}
+
+func (t *slogTracer) log(level slog.Level, msg, name string, key interface{}, li Listener, attrs ...slog.Attr) {
+	li = unwrap(li)
+	if name != "" {
+		attrs = append(attrs, slog.String("registry", name))
+	}
+	attrs = append(attrs,
+		slog.Any("key", key),
+		slog.String("type", fmt.Sprintf("%T", li)),
+	)
+	if in, ok := li.(Inspector); ok {
+		attrs = append(attrs,
+			slog.String("state", in.State().String()),
+			slog.Int("waiters", in.Waiters()),
+		)
+	}
+	t.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+func (t *slogTracer) access(name string, key interface{}, li Listener, found bool) {
+	if !t.enabled(t.level) || !t.sampled(key) {
+		return
+	}
+
+	msg := "listener.create"
+	if found {
+		msg = "listener.hit"
+	}
+	t.log(t.level.Level(), msg, name, key, li)
+}
+
+func (t *slogTracer) broadcast(name string, key interface{}, li Listener, value interface{}) func() {
+	if t.enabled(t.level) && t.sampled(key) {
+		t.log(t.level.Level(), "listener.broadcast", name, key, li,
+			slog.String("value_type", fmt.Sprintf("%T", value)),
+		)
+	}
+
+	return nil
+}
+
+func (t *slogTracer) wait(_ context.Context, name string, key interface{}, li Listener) func(value interface{}) {
+	logged := t.enabled(t.level) && t.sampled(key)
+	if !logged && (t.slow <= 0 || !t.enabled(t.slowLevel)) {
+		return nil
+	}
+	if logged {
+		t.log(t.level.Level(), "listener.wait", name, key, li)
+	}
+
+	start := time.Now()
+	return func(value interface{}) {
+		d := time.Since(start)
+		level := t.level.Level()
+		if t.slow > 0 && d >= t.slow {
+			level = t.slowLevel.Level()
+		} else if !logged {
+			return
+		}
+
+		t.log(level, "listener.wake", name, key, li,
+			slog.Duration("wait", d),
+			slog.String("value_type", fmt.Sprintf("%T", value)),
+		)
+	}
+}
+
+func (t *slogTracer) delete(name string, key interface{}, li Listener) {
+	if t.enabled(t.level) {
+		t.log(t.level.Level(), "listener.delete", name, key, li)
+	}
+	t.counts.Delete(key)
+}
@@ -0,0 +1,45 @@
+package listener
+
+type (
+	// Registries keep their listeners in a store. The default stores are
+	// a map guarded by a RWMutex (and sync.Map for Listeners on go1.9+);
+	// options such as WithShards replace them with other backends.
+	anyStore interface {
+		load(key interface{}) (Listener, bool)
+		loadOrCreate(key interface{}, c *config) (Listener, bool)
+		loadOrStore(key interface{}, li Listener) (Listener, bool)
+		swap(key interface{}, li Listener) Listener
+		loadAndDelete(key interface{}) (Listener, bool)
+		rangeAll(f func(key interface{}, li Listener) bool)
+		len() int
+	}
+
+	intStore interface {
+		load(key int) (Listener, bool)
+		loadOrCreate(key int, c *config) (Listener, bool)
+		loadOrStore(key int, li Listener) (Listener, bool)
+		swap(key int, li Listener) Listener
+		loadAndDelete(key int) (Listener, bool)
+		rangeAll(f func(key int, li Listener) bool)
+		len() int
+	}
+
+	stringStore interface {
+		load(key string) (Listener, bool)
+		loadOrCreate(key string, c *config) (Listener, bool)
+		loadOrStore(key string, li Listener) (Listener, bool)
+		swap(key string, li Listener) Listener
+		loadAndDelete(key string) (Listener, bool)
+		rangeAll(f func(key string, li Listener) bool)
+		len() int
+	}
+
+	backend struct {
+		anyStore    func(*config) anyStore
+		intStore    func(*config) intStore
+		stringStore func(*config) stringStore
+		anyBound    func(anyStore, *config) anyStore
+		intBound    func(intStore, *config) intStore
+		stringBound func(stringStore, *config) stringStore
+	}
+)
@@ -1,81 +1,194 @@
 package listener
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 type (
 	StringListeners struct {
-		creater func() Listener
-		lmap    map[string]Listener
-		mu      sync.RWMutex
+		config
+		store stringStore
+	}
+
+	stringMapStore struct {
+		lmap map[string]Listener
+		mu   sync.RWMutex
 	}
 )
 
 func NewStringListeners(creater ...func() Listener) *StringListeners {
-	var c func() Listener = NewListener
-	if len(creater) != 0 && creater[0] != nil {
-		c = creater[0]
-	}
+	return NewStringListenersWith(firstCreater(creater))
+}
 
-	return &StringListeners{
-		creater: c,
-		lmap:    make(map[string]Listener, 8),
+func NewStringListenersWith(opts ...Option) *StringListeners {
+	l := &StringListeners{
+		config: newConfig(opts),
+	}
+	if l.backend.stringStore != nil {
+		l.store = l.backend.stringStore(&l.config)
+	} else {
+		l.store = &stringMapStore{
+			lmap: make(map[string]Listener, 8),
+		}
+	}
+	if l.backend.stringBound != nil {
+		l.store = l.backend.stringBound(l.store, &l.config)
 	}
+
+	return l
 }
 
-func (l *StringListeners) GetOrCreate(key string) (li Listener, found bool) {
-	l.mu.RLock()
-	li, found = l.lmap[key]
-	l.mu.RUnlock()
+func (l *StringListeners) GetOrCreate(key string) (Listener, bool) {
+	li, found := l.store.load(key)
 	if !found {
-		l.mu.Lock()
-		li, found = l.lmap[key]
+		li, found = l.store.loadOrCreate(key, &l.config)
 		if !found {
-			li = l.creater()
-			l.lmap[key] = li
+			l.created(key, li)
+			return li, false
 		}
-		l.mu.Unlock()
+	}
+	l.metrics.Hit()
+	if l.keyed {
+		l.accessed(key, li)
 	}
 
-	return
+	return li, true
 }
 
-func (l *StringListeners) Get(key string) (li Listener, found bool) {
-	l.mu.RLock()
-	li, found = l.lmap[key]
-	l.mu.RUnlock()
+func (l *StringListeners) Get(key string) (Listener, bool) {
+	return l.store.load(key)
+}
 
-	return
+func (l *StringListeners) GetReceiver(key string) (Receiver, bool) {
+	if li, found := l.Get(key); found {
+		return ReadOnly(li), true
+	}
+
+	return nil, false
 }
 
 func (l *StringListeners) Len() int {
-	return len(l.lmap)
+	return l.store.len()
 }
 
 func (l *StringListeners) Delete(key string) {
-	l.mu.Lock()
-	delete(l.lmap, key)
-	l.mu.Unlock()
+	if li, found := l.store.loadAndDelete(key); found {
+		l.metrics.Deleted()
+		if l.keyed {
+			l.deleted(key, li)
+		}
+		if l.pooled {
+			l.release(li)
+		}
+	}
 }
 
-func (l *StringListeners) Put(key string, li Listener) (old Listener) {
-	l.mu.Lock()
-	old = l.lmap[key]
-	if li != nil {
-		l.lmap[key] = li
+func (l *StringListeners) Put(key string, li Listener) Listener {
+	if li == nil {
+		old, _ := l.store.load(key)
+		return old
 	}
-	l.mu.Unlock()
 
-	return
+	return l.store.swap(key, li)
 }
 
 func (l *StringListeners) Range(f func(key string, li Listener) bool) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	for key, li := range l.lmap {
+	l.store.rangeAll(f)
+}
+
+// BroadcastAfter broadcasts value after d to the listener of key, created
+// now if missing. Stop the returned timer to cancel.
+func (l *StringListeners) BroadcastAfter(key string, d time.Duration, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAfter(li, d, value)
+}
+
+// BroadcastAt is BroadcastAfter with an absolute time.
+func (l *StringListeners) BroadcastAt(key string, t time.Time, value interface{}) Timer {
+	li, _ := l.GetOrCreate(key)
+	return l.timers().broadcastAt(li, t, value)
+}
+
+// WaitUntilKey is WaitUntil on the listener of key, created now if
+// missing.
+func (l *StringListeners) WaitUntilKey(ctx context.Context, key string, pred func(value interface{}) bool) (interface{}, error) {
+	li, _ := l.GetOrCreate(key)
+	return WaitUntil(ctx, li, pred)
+}
+
+func (l *StringListeners) Stats() (s Stats) {
+	l.Range(func(_ string, li Listener) bool {
+		s.add(li)
+		return true
+	})
+
+	return
+}
+
+func (s *stringMapStore) load(key string) (li Listener, found bool) {
+	s.mu.RLock()
+	li, found = s.lmap[key]
+	s.mu.RUnlock()
+
+	return
+}
+
+func (s *stringMapStore) loadOrCreate(key string, c *config) (li Listener, found bool) {
+	s.mu.Lock()
+	li, found = s.lmap[key]
+	if !found {
+		li = c.newListener(key)
+		s.lmap[key] = li
+	}
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *stringMapStore) loadOrStore(key string, li Listener) (old Listener, found bool) {
+	s.mu.Lock()
+	if old, found = s.lmap[key]; !found {
+		s.lmap[key] = li
+		old = li
+	}
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *stringMapStore) swap(key string, li Listener) (old Listener) {
+	s.mu.Lock()
+	old = s.lmap[key]
+	s.lmap[key] = li
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *stringMapStore) loadAndDelete(key string) (li Listener, found bool) {
+	s.mu.Lock()
+	li, found = s.lmap[key]
+	delete(s.lmap, key)
+	s.mu.Unlock()
+
+	return
+}
+
+func (s *stringMapStore) rangeAll(f func(key string, li Listener) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, li := range s.lmap {
 		if !f(key, li) {
 			break
 		}
 	}
 }
+
+func (s *stringMapStore) len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.lmap)
+}
@@ -0,0 +1,22 @@
+//go:build go1.20
+// +build go1.20
+
+package listener
+
+func (s *syncMapStore) swap(key interface{}, li Listener) Listener {
+	old, found := s.lmap.Swap(key, li)
+	if !found {
+		return nil
+	}
+
+	return old.(Listener)
+}
+
+func (s *syncMapStore) loadAndDelete(key interface{}) (Listener, bool) {
+	li, found := s.lmap.LoadAndDelete(key)
+	if !found {
+		return nil, false
+	}
+
+	return li.(Listener), true
+}
@@ -0,0 +1,34 @@
+//go:build go1.9 && !go1.20
+// +build go1.9,!go1.20
+
+package listener
+
+// Without Swap and LoadAndDelete, replacing and removing keys take s.mu.
+// The only other writer is LoadOrStore, which never touches a present
+// key, so a key seen under s.mu stays until it is stored or deleted.
+
+func (s *syncMapStore) swap(key interface{}, li Listener) Listener {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, found := s.lmap.LoadOrStore(key, li)
+	if !found {
+		return nil
+	}
+	s.lmap.Store(key, li)
+
+	return old.(Listener)
+}
+
+func (s *syncMapStore) loadAndDelete(key interface{}) (Listener, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	li, found := s.lmap.Load(key)
+	if !found {
+		return nil, false
+	}
+	s.lmap.Delete(key)
+
+	return li.(Listener), true
+}
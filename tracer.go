@@ -0,0 +1,31 @@
+package listener
+
+import (
+	"context"
+)
+
+type (
+	// tracer receives keyed lifecycle events from registries and observed
+	// listeners. broadcast and wait are called before the listener is
+	// touched and may return a function that is called afterwards, with
+	// the value for wait, on the same goroutine. wait gets the caller's
+	// context, context.Background() for Wait.
+	tracer interface {
+		access(name string, key interface{}, li Listener, found bool)
+		broadcast(name string, key interface{}, li Listener, value interface{}) func()
+		wait(ctx context.Context, name string, key interface{}, li Listener) func(value interface{})
+		delete(name string, key interface{}, li Listener)
+	}
+)
+
+func withTracer(t tracer) Option {
+	return func(c *config) {
+		c.tracers = append(c.tracers, t)
+	}
+}
+
+func (c *config) accessed(key interface{}, li Listener) {
+	for _, t := range c.tracers {
+		t.access(c.name, key, li, true)
+	}
+}
@@ -0,0 +1,54 @@
+package listener
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// ExpiringBroadcaster is implemented by listeners whose values can go
+	// stale; after ttl Receive reports nothing and Wait blocks again
+	// until the next broadcast.
+	ExpiringBroadcaster interface {
+		BroadcastTTL(value interface{}, ttl time.Duration)
+	}
+)
+
+var (
+	_ ExpiringBroadcaster = &listener{}
+	_ ExpiringBroadcaster = &observed{}
+)
+
+// WithTTL makes every Broadcast value expire after ttl.
+func WithTTL(ttl time.Duration) ListenerOption {
+	return func(l *listener) {
+		l.ttl = ttl
+	}
+}
+
+// WithClock sets the clock used for timestamps and expiry.
+func WithClock(clock Clock) ListenerOption {
+	return func(l *listener) {
+		l.clock = clock
+	}
+}
+
+// BroadcastTTL broadcasts value so that it expires after ttl (never, if
+// ttl <= 0). Listeners that cannot expire values just broadcast it.
+func BroadcastTTL(li Listener, value interface{}, ttl time.Duration) {
+	if eb, ok := li.(ExpiringBroadcaster); ok {
+		eb.BroadcastTTL(value, ttl)
+		return
+	}
+	li.Broadcast(value)
+}
+
+func (l *listener) BroadcastTTL(value interface{}, ttl time.Duration) {
+	atomic.AddUint64(&l.count, 1)
+
+	l.mu.Lock()
+	if l.closed == 0 {
+		l.fire(value, ttl)
+	}
+	l.mu.Unlock()
+}
@@ -0,0 +1,172 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// UntilWaiter is implemented by listeners that can wait for a value
+	// matching a predicate.
+	UntilWaiter interface {
+		WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error)
+	}
+)
+
+var (
+	ErrUnsupported = errors.New("listener: not supported")
+	ErrNoMatch     = errors.New("listener: no value will match")
+
+	_ UntilWaiter = &listener{}
+	_ UntilWaiter = &listenerOnce{}
+	_ UntilWaiter = &replayListener{}
+	_ UntilWaiter = &observed{}
+	_ UntilWaiter = &receiver{}
+)
+
+// WaitUntil blocks until li holds a value for which pred returns true and
+// returns it. Pred sees the value li holds when it wakes up, maybe more
+// than once; a value replaced before that is never seen. It fails with
+// ctx's error, with ErrClosed if li gets closed before a value matches,
+// with ErrNoMatch if li fired with a value that does not match and will
+// not fire again, and with ErrUnsupported if li cannot wait this way.
+func WaitUntil(ctx context.Context, li Receiver, pred func(value interface{}) bool) (interface{}, error) {
+	if uw, ok := li.(UntilWaiter); ok {
+		return uw.WaitUntil(ctx, pred)
+	}
+	if l, ok := li.(Listener); ok {
+		if uw, ok := unwrap(l).(UntilWaiter); ok {
+			return uw.WaitUntil(ctx, pred)
+		}
+	}
+
+	return nil, ErrUnsupported
+}
+
+// notify must be called with l.mu held.
+func (l *listener) notify() {
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+}
+
+func (l *listener) WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error) {
+	atomic.AddInt32(&l.waiters, 1)
+	defer atomic.AddInt32(&l.waiters, -1)
+
+	var changed chan struct{}
+	for first := true; ; first = false {
+		l.mu.Lock()
+		value, ok := l.value, l.trigger != 0 && !l.expired()
+		closed := l.closed != 0
+		if !first {
+			// only now pay for the channel, then check again
+			if l.changed == nil {
+				l.changed = make(chan struct{})
+			}
+			changed = l.changed
+		}
+		l.mu.Unlock()
+
+		if ok && pred(value) {
+			return value, nil
+		}
+		if closed {
+			return nil, ErrClosed
+		}
+		if first {
+			continue
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// WaitUntil on a once listener checks the one value it will ever get.
+func (l *listenerOnce) WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error) {
+	select {
+	case <-l.done:
+	default:
+		atomic.AddInt32(&l.waiters, 1)
+		select {
+		case <-l.done:
+			atomic.AddInt32(&l.waiters, -1)
+		case <-ctx.Done():
+			atomic.AddInt32(&l.waiters, -1)
+			return nil, ctx.Err()
+		}
+	}
+
+	return onceUntil(pred, l.value, l.State() == StateClosed)
+}
+
+// onceUntil finishes WaitUntil on a once listener that holds value.
+func onceUntil(pred func(value interface{}) bool, value interface{}, closed bool) (interface{}, error) {
+	if pred(value) {
+		return value, nil
+	}
+	if closed {
+		return nil, ErrClosed
+	}
+
+	return nil, ErrNoMatch
+}
+
+func (l *replayListener) WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error) {
+	atomic.AddInt32(&l.waiters, 1)
+	defer atomic.AddInt32(&l.waiters, -1)
+
+	for {
+		l.mu.Lock()
+		value, ok, err := l.value, l.next != 0, l.err
+		changed := l.wait()
+		l.mu.Unlock()
+
+		if ok && pred(value) {
+			return value, nil
+		}
+		if err != nil {
+			return nil, ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (o *observed) WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error) {
+	var wakes []func(interface{})
+	for _, t := range o.cfg.tracers {
+		if wake := t.wait(ctx, o.cfg.name, o.key, o.Listener); wake != nil {
+			wakes = append(wakes, wake)
+		}
+	}
+
+	o.cfg.metrics.WaitStarted()
+	start := time.Now()
+	value, err := WaitUntil(ctx, o.Listener, pred)
+	o.cfg.metrics.WaitDone(time.Since(start))
+
+	for i := len(wakes) - 1; i >= 0; i-- {
+		wakes[i](value)
+	}
+	if err == nil {
+		for _, ic := range o.cfg.interceptors {
+			ic.OnWake(o.key, value)
+		}
+	}
+
+	return value, err
+}
+
+func (r *receiver) WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error) {
+	return WaitUntil(ctx, r.li.Listener, pred)
+}
@@ -0,0 +1,155 @@
+package listener
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+type (
+	// Updater is implemented by listeners whose value can be changed
+	// atomically; the resend listener is one.
+	Updater interface {
+		Update(f func(old interface{}, ok bool) interface{})
+		CompareAndBroadcast(old, new interface{}) bool
+	}
+
+	// updater is the read-modify-write step behind Updater: f sees the
+	// current value and returns the new one, or false to leave it.
+	updater interface {
+		update(f func(old interface{}, ok bool) (interface{}, bool)) (bool, error)
+	}
+)
+
+var (
+	_ Updater = &listener{}
+
+	_ updater = &listener{}
+	_ updater = &observed{}
+)
+
+// Update atomically replaces the value of li with f(old, ok), where ok
+// reports whether li held a value. f runs with li locked and must not
+// call back into it. It fails with ErrUnsupported if li does not support
+// updates, which includes wrappers such as NewBatched or NewThrottled,
+// or with the error of an interceptor that vetoed the value.
+func Update(li Listener, f func(old interface{}, ok bool) interface{}) error {
+	_, err := update(li, func(old interface{}, ok bool) (interface{}, bool) {
+		return f(old, ok), true
+	})
+
+	return err
+}
+
+// CompareAndBroadcast broadcasts new to li if its value is old (nil if it
+// has none) and reports whether it did. Uncomparable values, such as
+// maps, never match.
+func CompareAndBroadcast(li Listener, old, new interface{}) (bool, error) {
+	return update(li, func(cur interface{}, ok bool) (interface{}, bool) {
+		return new, equal(cur, old)
+	})
+}
+
+// update only goes through wrappers that implement updater themselves;
+// the others change what is broadcast and cannot be bypassed.
+func update(li Listener, f func(old interface{}, ok bool) (interface{}, bool)) (bool, error) {
+	if u, ok := li.(updater); ok {
+		return u.update(f)
+	}
+
+	return false, ErrUnsupported
+}
+
+// equal is a == b that reports false where == would panic, including
+// for comparable types that hold an uncomparable value in an interface.
+func equal(a, b interface{}) (eq bool) {
+	if a == nil || b == nil {
+		return a == b
+	}
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			eq = false
+		}
+	}()
+
+	return a == b
+}
+
+// Update replaces the value with f(old, ok) under the listener's lock, so
+// concurrent updates are never lost and waiters see them in order.
+func (l *listener) Update(f func(old interface{}, ok bool) interface{}) {
+	l.update(func(old interface{}, ok bool) (interface{}, bool) {
+		return f(old, ok), true
+	})
+}
+
+// CompareAndBroadcast broadcasts new if the value is old (nil if there is
+// none) and reports whether it did. Uncomparable values never match.
+func (l *listener) CompareAndBroadcast(old, new interface{}) bool {
+	fired, _ := l.update(func(cur interface{}, ok bool) (interface{}, bool) {
+		return new, equal(cur, old)
+	})
+
+	return fired
+}
+
+func (l *listener) update(f func(old interface{}, ok bool) (interface{}, bool)) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed != 0 {
+		return false, nil
+	}
+
+	var old interface{}
+	ok := l.trigger != 0 && !l.expired()
+	if ok {
+		old = l.value
+	}
+	value, fire := f(old, ok)
+	if !fire {
+		return false, nil
+	}
+
+	atomic.AddUint64(&l.count, 1)
+	l.fire(value, l.ttl)
+
+	return true, nil
+}
+
+func (o *observed) update(f func(old interface{}, ok bool) (interface{}, bool)) (bool, error) {
+	var vetoed error
+	var value interface{}
+	fired, err := update(o.Listener, func(old interface{}, ok bool) (interface{}, bool) {
+		v, fire := f(old, ok)
+		if !fire {
+			return nil, false
+		}
+		for _, ic := range o.cfg.interceptors {
+			if v, vetoed = ic.OnBroadcast(o.key, v); vetoed != nil {
+				return nil, false
+			}
+		}
+		value = v
+		return v, true
+	})
+	if err != nil {
+		return false, err
+	}
+	if vetoed != nil {
+		return false, vetoed
+	}
+
+	if fired {
+		o.cfg.metrics.Broadcast()
+		for _, t := range o.cfg.tracers {
+			if done := t.broadcast(o.cfg.name, o.key, o.Listener, value); done != nil {
+				done()
+			}
+		}
+	}
+
+	return fired, nil
+}
@@ -0,0 +1,185 @@
+package listener
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type (
+	StuckWaiter struct {
+		Registry string
+		Key      interface{}
+		Since    time.Time
+		Waited   time.Duration
+		// Stack of the goroutine at the time it called Wait, if
+		// WatchdogOptions.Stack is set.
+		Stack []byte
+	}
+
+	WatchdogOptions struct {
+		Clock Clock
+		// Stack captures the stack of every Wait that blocks.
+		Stack bool
+	}
+
+	// Watchdog tracks goroutines parked in Wait on listeners of registries
+	// created with WithWatchdog and reports those that wait longer than
+	// a threshold. Each waiter is reported once.
+	Watchdog struct {
+		threshold time.Duration
+		report    func(StuckWaiter)
+		clock     Clock
+		stack     bool
+		mu        sync.Mutex
+		waiters   map[*watchedWaiter]struct{}
+		stop      chan struct{}
+	}
+
+	watchedWaiter struct {
+		StuckWaiter
+		reported bool
+	}
+)
+
+var (
+	_ tracer = &Watchdog{}
+)
+
+func NewWatchdog(threshold time.Duration, report func(StuckWaiter), opts ...WatchdogOptions) *Watchdog {
+	var o WatchdogOptions
+	if len(opts) != 0 {
+		o = opts[0]
+	}
+	if o.Clock == nil {
+		o.Clock = SystemClock
+	}
+	if report == nil {
+		report = func(StuckWaiter) {}
+	}
+
+	return &Watchdog{
+		threshold: threshold,
+		report:    report,
+		clock:     o.Clock,
+		stack:     o.Stack,
+		waiters:   make(map[*watchedWaiter]struct{}),
+	}
+}
+
+func WithWatchdog(w *Watchdog) Option {
+	if w == nil {
+		return func(*config) {}
+	}
+
+	return withTracer(w)
+}
+
+// Check reports waiters that became stuck since the previous check and
+// returns all waiters that are stuck now.
+func (w *Watchdog) Check() (stuck []StuckWaiter) {
+	now := w.clock.Now()
+	var report []StuckWaiter
+
+	w.mu.Lock()
+	for ww := range w.waiters {
+		waited := now.Sub(ww.Since)
+		if waited < w.threshold {
+			continue
+		}
+		sw := ww.StuckWaiter
+		sw.Waited = waited
+		stuck = append(stuck, sw)
+		if !ww.reported {
+			ww.reported = true
+			report = append(report, sw)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, sw := range report {
+		w.report(sw)
+	}
+
+	return
+}
+
+// Start runs Check every interval until Stop is called.
+func (w *Watchdog) Start(interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		tic := time.NewTicker(interval)
+		defer tic.Stop()
+		for {
+			select {
+			case <-tic.C:
+				w.Check()
+			case <-stop:
+				return
+			}
+		}
+	}(w.stop)
+}
+
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+	w.mu.Unlock()
+}
+
+func (w *Watchdog) access(string, interface{}, Listener, bool) {}
+
+func (w *Watchdog) broadcast(string, interface{}, Listener, interface{}) func() {
+	return nil
+}
+
+func (w *Watchdog) delete(string, interface{}, Listener) {}
+
+func (w *Watchdog) wait(_ context.Context, name string, key interface{}, li Listener) func(value interface{}) {
+	if _, ok := li.Receive(); ok {
+		// Wait returns at once
+		return nil
+	}
+
+	ww := &watchedWaiter{
+		StuckWaiter: StuckWaiter{
+			Registry: name,
+			Key:      key,
+			Since:    w.clock.Now(),
+		},
+	}
+	if w.stack {
+		ww.Stack = stack()
+	}
+
+	w.mu.Lock()
+	w.waiters[ww] = struct{}{}
+	w.mu.Unlock()
+
+	return func(interface{}) {
+		w.mu.Lock()
+		delete(w.waiters, ww)
+		w.mu.Unlock()
+	}
+}
+
+func stack() []byte {
+	buf := make([]byte, 1024)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
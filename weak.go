@@ -0,0 +1,258 @@
+//go:build go1.24
+// +build go1.24
+
+package listener
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+	"weak"
+)
+
+type (
+	// weakListener is what weak registries hand out. The registry only
+	// keeps a weak pointer to it, so the entry goes away once no holder
+	// of the handle is left.
+	weakListener struct {
+		wrapper
+	}
+
+	weakStore[K comparable] struct {
+		cfg  *config
+		lmap map[K]weak.Pointer[weakListener]
+		// gone holds entries removed after their handle was collected;
+		// their cleanup still reports them.
+		gone map[weak.Pointer[weakListener]]struct{}
+		// pinned keeps listeners stored with Put alive until they are
+		// deleted or replaced.
+		pinned  map[K]*weakListener
+		mu      sync.RWMutex
+		dropped func(key K)
+	}
+
+	weakEntry[K comparable] struct {
+		key K
+		ptr weak.Pointer[weakListener]
+		li  Listener // the wrapped listener, never the handle itself
+	}
+)
+
+var (
+	_ Listener  = &weakListener{}
+	_ Inspector = &weakListener{}
+	_ Closer    = &weakListener{}
+
+	_ ExpiringBroadcaster = &weakListener{}
+	_ UntilWaiter         = &weakListener{}
+	_ updater             = &weakListener{}
+
+	_ dropper[int] = &weakStore[int]{}
+
+	_ anyStore    = &weakStore[interface{}]{}
+	_ intStore    = &weakStore[int]{}
+	_ stringStore = &weakStore[string]{}
+)
+
+// WithWeakRefs selects a registry backend that holds listeners weakly:
+// a key is dropped, and reported like a Delete, once the garbage
+// collector finds that nobody holds a listener the registry returned for
+// it anymore. Goroutines blocked in Wait count as holders. Listeners
+// stored with Put are held strongly until they are deleted or replaced,
+// since the caller keeps the listener rather than the registry's handle.
+func WithWeakRefs() Option {
+	return func(c *config) {
+		c.backend.anyStore = func(c *config) anyStore {
+			return newWeakStore[interface{}](c)
+		}
+		c.backend.intStore = func(c *config) intStore {
+			return newWeakStore[int](c)
+		}
+		c.backend.stringStore = func(c *config) stringStore {
+			return newWeakStore[string](c)
+		}
+	}
+}
+
+func newWeakStore[K comparable](c *config) *weakStore[K] {
+	return &weakStore[K]{
+		cfg:    c,
+		lmap:   make(map[K]weak.Pointer[weakListener]),
+		gone:   make(map[weak.Pointer[weakListener]]struct{}),
+		pinned: make(map[K]*weakListener),
+	}
+}
+
+// add must be called with s.mu held.
+func (s *weakStore[K]) add(key K, li Listener, pin bool) *weakListener {
+	w, ok := li.(*weakListener)
+	if !ok {
+		w = &weakListener{wrapper{li}}
+	}
+	if pin {
+		s.pinned[key] = w
+	} else {
+		delete(s.pinned, key)
+	}
+	ptr := weak.Make(w)
+	s.lmap[key] = ptr
+	runtime.AddCleanup(w, s.collect, weakEntry[K]{key: key, ptr: ptr, li: w.Listener})
+
+	return w
+}
+
+// live returns the handle behind ptr. A collected one is moved to gone
+// when it is removed, so it must be called with s.mu held.
+func (s *weakStore[K]) live(ptr weak.Pointer[weakListener]) *weakListener {
+	w := ptr.Value()
+	if w == nil {
+		s.gone[ptr] = struct{}{}
+	}
+
+	return w
+}
+
+func (s *weakStore[K]) collect(e weakEntry[K]) {
+	s.mu.Lock()
+	if ptr, found := s.lmap[e.key]; found && ptr == e.ptr {
+		delete(s.lmap, e.key)
+	} else if _, found := s.gone[e.ptr]; found {
+		delete(s.gone, e.ptr)
+	} else {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	if s.dropped != nil {
+		s.dropped(e.key)
+	}
+	c := s.cfg
+	c.metrics.Deleted()
+	if c.keyed {
+		c.deleted(e.key, e.li)
+	}
+	if c.pooled {
+		c.release(e.li)
+	}
+}
+
+func (s *weakStore[K]) onDrop(f func(key K)) {
+	s.dropped = f
+}
+
+func (s *weakStore[K]) load(key K) (Listener, bool) {
+	s.mu.RLock()
+	ptr, found := s.lmap[key]
+	s.mu.RUnlock()
+	if !found {
+		return nil, false
+	}
+	if w := ptr.Value(); w != nil {
+		return w, true
+	}
+
+	return nil, false
+}
+
+func (s *weakStore[K]) loadOrCreate(key K, c *config) (Listener, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ptr, found := s.lmap[key]; found {
+		if w := s.live(ptr); w != nil {
+			return w, true
+		}
+	}
+
+	return s.add(key, c.newListener(key), false), false
+}
+
+func (s *weakStore[K]) loadOrStore(key K, li Listener) (Listener, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ptr, found := s.lmap[key]; found {
+		if w := s.live(ptr); w != nil {
+			return w, true
+		}
+	}
+	s.add(key, li, true)
+
+	return li, false
+}
+
+func (s *weakStore[K]) swap(key K, li Listener) Listener {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var old Listener
+	if ptr, found := s.lmap[key]; found {
+		if w := s.live(ptr); w != nil {
+			old = w
+		}
+	}
+	s.add(key, li, true)
+
+	return old
+}
+
+func (s *weakStore[K]) loadAndDelete(key K) (Listener, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ptr, found := s.lmap[key]
+	if !found {
+		return nil, false
+	}
+	delete(s.lmap, key)
+	delete(s.pinned, key)
+	if w := s.live(ptr); w != nil {
+		return w, true
+	}
+
+	return nil, false
+}
+
+func (s *weakStore[K]) rangeAll(f func(key K, li Listener) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, ptr := range s.lmap {
+		if w := ptr.Value(); w != nil && !f(key, w) {
+			break
+		}
+	}
+}
+
+func (s *weakStore[K]) len() (n int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, ptr := range s.lmap {
+		if ptr.Value() != nil {
+			n++
+		}
+	}
+
+	return
+}
+
+func (w *weakListener) Wait() interface{} {
+	defer runtime.KeepAlive(w)
+	return w.Listener.Wait()
+}
+
+func (w *weakListener) WaitUntil(ctx context.Context, pred func(value interface{}) bool) (interface{}, error) {
+	defer runtime.KeepAlive(w)
+	return WaitUntil(ctx, w.Listener, pred)
+}
+
+func (w *weakListener) update(f func(old interface{}, ok bool) (interface{}, bool)) (bool, error) {
+	return update(w.Listener, f)
+}
+
+func (w *weakListener) BroadcastTTL(value interface{}, ttl time.Duration) {
+	BroadcastTTL(w.Listener, value, ttl)
+}
+
+func (w *weakListener) Close(err error) {
+	if c, ok := w.Listener.(Closer); ok {
+		c.Close(err)
+	}
+}
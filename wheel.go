@@ -0,0 +1,286 @@
+package listener
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	wheelBits   = 6
+	wheelSize   = 1 << wheelBits
+	wheelMask   = wheelSize - 1
+	wheelLevels = 4
+	wheelSpan   = 1 << (wheelBits * wheelLevels) // ticks covered before clamping
+)
+
+type (
+	// TimerWheel schedules callbacks on a hierarchical timing wheel: each
+	// level has 64 slots, each 64 times wider than the slots below it.
+	// Scheduling and cancelling is O(1) and one runtime timer drives the
+	// whole wheel, so millions of pending timeouts stay cheap. Callbacks
+	// run one after another and may be up to a tick late.
+	TimerWheel struct {
+		mu      sync.Mutex
+		clock   Clock
+		tick    int64     // ns
+		start   time.Time // tick 0
+		cur     int64     // last processed tick
+		levels  [wheelLevels][wheelSize]wheelSlot
+		counts  [wheelLevels]int
+		pending int
+		timer   Timer // drives run, nil while idle
+		armedAt int64
+	}
+
+	wheelSlot struct {
+		head *wheelTimer
+	}
+
+	wheelTimer struct {
+		wheel      *TimerWheel
+		at         int64 // tick
+		level      int
+		slot       *wheelSlot
+		prev, next *wheelTimer
+		li         Listener
+		value      interface{}
+		f          func()
+	}
+)
+
+var (
+	_ Timer = &wheelTimer{}
+
+	defaultWheel = NewTimerWheel(time.Millisecond, nil)
+)
+
+// NewTimerWheel makes a wheel with the given tick (1ms if <= 0) driven by
+// clock (SystemClock if nil).
+func NewTimerWheel(tick time.Duration, clock Clock) *TimerWheel {
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &TimerWheel{
+		clock: clock,
+		tick:  int64(tick),
+		start: clock.Now(),
+	}
+}
+
+// WithTimerWheel sets the wheel behind BroadcastAfter and BroadcastAt; by
+// default all registries share one with a 1ms tick.
+func WithTimerWheel(w *TimerWheel) Option {
+	return func(c *config) {
+		c.wheel = w
+	}
+}
+
+func (c *config) timers() *TimerWheel {
+	if c.wheel != nil {
+		return c.wheel
+	}
+
+	return defaultWheel
+}
+
+// AfterFunc calls f after d.
+func (w *TimerWheel) AfterFunc(d time.Duration, f func()) Timer {
+	return w.schedule(w.clock.Now().Add(d), &wheelTimer{f: f})
+}
+
+// Len returns the number of pending timers.
+func (w *TimerWheel) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.pending
+}
+
+func (w *TimerWheel) broadcastAt(li Listener, at time.Time, value interface{}) Timer {
+	return w.schedule(at, &wheelTimer{li: li, value: value})
+}
+
+func (w *TimerWheel) broadcastAfter(li Listener, d time.Duration, value interface{}) Timer {
+	return w.broadcastAt(li, w.clock.Now().Add(d), value)
+}
+
+func (w *TimerWheel) schedule(at time.Time, t *wheelTimer) Timer {
+	t.wheel = w
+	ns := int64(at.Sub(w.start))
+	t.at = (ns + w.tick - 1) / w.tick
+
+	w.mu.Lock()
+	if t.at <= w.cur {
+		t.at = w.cur + 1
+	}
+	w.add(t)
+	w.pending++
+	if w.timer == nil || t.at < w.armedAt {
+		w.arm()
+	}
+	w.mu.Unlock()
+
+	return t
+}
+
+// add must be called with w.mu held and t.at > w.cur.
+func (w *TimerWheel) add(t *wheelTimer) {
+	delta := t.at - w.cur
+	level := 0
+	for level < wheelLevels-1 && delta >= 1<<(wheelBits*(level+1)) {
+		level++
+	}
+	at := t.at
+	if delta >= wheelSpan {
+		// parked in the last slot reachable; cascades back in later
+		at = w.cur + wheelSpan - 1
+	}
+
+	slot := &w.levels[level][(at>>(wheelBits*level))&wheelMask]
+	t.level = level
+	t.slot = slot
+	t.prev = nil
+	t.next = slot.head
+	if slot.head != nil {
+		slot.head.prev = t
+	}
+	slot.head = t
+	w.counts[level]++
+}
+
+// unlink must be called with w.mu held.
+func (w *TimerWheel) unlink(t *wheelTimer) {
+	if t.prev != nil {
+		t.prev.next = t.next
+	} else {
+		t.slot.head = t.next
+	}
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+	w.counts[t.level]--
+	t.slot, t.prev, t.next = nil, nil, nil
+}
+
+// advance processes all ticks up to to and returns the timers that are
+// due. It must be called with w.mu held.
+func (w *TimerWheel) advance(to int64) (due []*wheelTimer) {
+	for w.cur < to {
+		if w.pending == 0 {
+			w.cur = to
+			break
+		}
+		if w.counts[0] == 0 {
+			// nothing below the next cascade; jump right before it
+			if next := w.cur | wheelMask; next > w.cur {
+				if next > to {
+					next = to
+				}
+				w.cur = next
+				continue
+			}
+		}
+
+		w.cur++
+		for level := 1; level < wheelLevels; level++ {
+			if w.cur&(1<<(wheelBits*level)-1) != 0 {
+				break
+			}
+			slot := &w.levels[level][(w.cur>>(wheelBits*level))&wheelMask]
+			for t := slot.head; t != nil; {
+				next := t.next
+				w.unlink(t)
+				if t.at <= w.cur {
+					due = append(due, t)
+				} else {
+					w.add(t)
+				}
+				t = next
+			}
+		}
+
+		slot := &w.levels[0][w.cur&wheelMask]
+		for t := slot.head; t != nil; {
+			next := t.next
+			w.unlink(t)
+			due = append(due, t)
+			t = next
+		}
+	}
+	w.pending -= len(due)
+
+	return
+}
+
+// arm schedules the next run. It must be called with w.mu held.
+func (w *TimerWheel) arm() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.pending == 0 {
+		return
+	}
+
+	next := w.next()
+	w.armedAt = next
+	d := time.Duration(next*w.tick) - w.clock.Now().Sub(w.start)
+	w.timer = afterFunc(w.clock, d, w.run)
+}
+
+// next returns the first tick with work: a due slot on the lowest level
+// or a cascade of a non-empty slot above it.
+func (w *TimerWheel) next() int64 {
+	next := int64(-1)
+	for level := 0; level < wheelLevels; level++ {
+		if w.counts[level] == 0 {
+			continue
+		}
+		shift := uint(wheelBits * level)
+		block := w.cur >> shift
+		for i := int64(1); i <= wheelSize; i++ {
+			if w.levels[level][(block+i)&wheelMask].head != nil {
+				if tick := (block + i) << shift; next < 0 || tick < next {
+					next = tick
+				}
+				break
+			}
+		}
+	}
+
+	return next
+}
+
+func (w *TimerWheel) run() {
+	w.mu.Lock()
+	w.timer = nil
+	due := w.advance(int64(w.clock.Now().Sub(w.start)) / w.tick)
+	w.arm()
+	w.mu.Unlock()
+
+	for _, t := range due {
+		if t.f != nil {
+			t.f()
+		} else {
+			t.li.Broadcast(t.value)
+		}
+	}
+}
+
+// Stop cancels the timer and reports whether it was still pending.
+func (t *wheelTimer) Stop() bool {
+	w := t.wheel
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t.slot == nil {
+		return false
+	}
+	w.unlink(t)
+	w.pending--
+
+	return true
+}
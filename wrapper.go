@@ -0,0 +1,74 @@
+package listener
+
+import (
+	"time"
+)
+
+type (
+	// wrapper is embedded by listeners that wrap another one. It forwards
+	// Unwrap and Inspector to the wrapped listener.
+	wrapper struct {
+		Listener
+	}
+)
+
+var (
+	_ Listener  = wrapper{}
+	_ Inspector = wrapper{}
+)
+
+func (w wrapper) Unwrap() Listener {
+	return w.Listener
+}
+
+func (w wrapper) State() State {
+	if in, ok := unwrap(w.Listener).(Inspector); ok {
+		return in.State()
+	}
+
+	return StatePending
+}
+
+func (w wrapper) Waiters() int {
+	if in, ok := unwrap(w.Listener).(Inspector); ok {
+		return in.Waiters()
+	}
+
+	return 0
+}
+
+func (w wrapper) CreatedAt() time.Time {
+	if in, ok := unwrap(w.Listener).(Inspector); ok {
+		return in.CreatedAt()
+	}
+
+	return time.Time{}
+}
+
+func (w wrapper) FiredAt() time.Time {
+	if in, ok := unwrap(w.Listener).(Inspector); ok {
+		return in.FiredAt()
+	}
+
+	return time.Time{}
+}
+
+func (w wrapper) BroadcastCount() uint64 {
+	if in, ok := unwrap(w.Listener).(Inspector); ok {
+		return in.BroadcastCount()
+	}
+
+	return 0
+}
+
+func unwrap(li Listener) Listener {
+	for {
+		u, ok := li.(interface {
+			Unwrap() Listener
+		})
+		if !ok {
+			return li
+		}
+		li = u.Unwrap()
+	}
+}